@@ -0,0 +1,110 @@
+package getopt
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrRepeatedOpt is returned by [Once] when an option is given more than once.
+var ErrRepeatedOpt = errors.New("getopt: option given more than once")
+
+// A RepeatError describes an option given more than once, naming both
+// occurrences as they were typed.
+type RepeatError struct {
+	First  Result // first occurrence of the option
+	Second Result // second occurrence of the option
+}
+
+func (e *RepeatError) Error() string {
+	return fmt.Sprintf("%v: %s (argument %d) and %s (argument %d)",
+		ErrRepeatedOpt, e.First.Text, e.First.Ind, e.Second.Text, e.Second.Ind)
+}
+
+func (e *RepeatError) Unwrap() error {
+	return ErrRepeatedOpt
+}
+
+// The functions below aggregate the results of parsing an option that may be
+// given more than once. The option is identified by its short option character
+// char and long option name, so results from either alias are unified. Either
+// may be zero if the option has no such alias. Results from a long option with
+// a linked [LongOpt.Char] also match char.
+
+// Count returns the number of results for the option (e.g., for -vvv).
+func Count(results []Result, char rune, name string) (count int) {
+	for _, res := range results {
+		if res.matches(char, name) {
+			count++
+		}
+	}
+	return count
+}
+
+// First returns the first result for the option, and whether it was found.
+func First(results []Result, char rune, name string) (res Result, found bool) {
+	for _, res := range results {
+		if res.matches(char, name) {
+			return res, true
+		}
+	}
+	return res, false
+}
+
+// Last returns the last result for the option, and whether it was found (e.g.,
+// when the last value given wins).
+func Last(results []Result, char rune, name string) (res Result, found bool) {
+	for i := len(results) - 1; i >= 0; i-- {
+		if results[i].matches(char, name) {
+			return results[i], true
+		}
+	}
+	return res, false
+}
+
+// Every returns all results for the option, in the order they were given.
+func Every(results []Result, char rune, name string) (matched []Result) {
+	for _, res := range results {
+		if res.matches(char, name) {
+			matched = append(matched, res)
+		}
+	}
+	return matched
+}
+
+// OptArgs returns the arguments of all results for the option, in the order
+// they were given (e.g., for -I paths), as returned by [Result.OptArgs]. An
+// option with [NArgs] contributes all of its arguments, and an option given
+// without an argument contributes none.
+func OptArgs(results []Result, char rune, name string) (optArgs []string) {
+	for _, res := range Every(results, char, name) {
+		optArgs = append(optArgs, res.OptArgs()...)
+	}
+	return optArgs
+}
+
+// Once returns the result for an option that may be given at most once, and
+// whether it was found. If the option was given more than once, err is a
+// [*RepeatError] naming the first two occurrences.
+func Once(results []Result, char rune, name string) (res Result, found bool, err error) {
+	for _, r := range results {
+		if !r.matches(char, name) {
+			continue
+		}
+		if found {
+			return res, true, &RepeatError{First: res, Second: r}
+		}
+		res, found = r, true
+	}
+	return res, found, nil
+}
+
+// matches reports whether res was parsed from the option identified by char or
+// name.
+func (res Result) matches(char rune, name string) bool {
+	switch res.Kind {
+	case KindShortOpt, KindLongOpt:
+		return char != 0 && res.Char == char || name != "" && res.Name == name
+	default:
+		return false
+	}
+}
@@ -0,0 +1,138 @@
+package getopt
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// testAggregateConfig returns a Config with short and long aliases of the same
+// options, for aggregating results.
+func testAggregateConfig() Config {
+	opts, longOpts := SolarisOptStr(`v(verbose)I:(include)o:(output)`)
+	return Config{
+		Opts:     opts,
+		LongOpts: append(longOpts, LongOptStr(`level:,debug`)...),
+		Func:     FuncGetOptLong,
+		Mode:     ModeInOrder,
+	}
+}
+
+func TestCount(t *testing.T) {
+	results := testParse(t, `prgm -vv p1 --verb -I a --debug`, testAggregateConfig())
+
+	tests := []struct {
+		char rune
+		name string
+		want int
+	}{
+		{'v', "verbose", 3},
+		{'v', "", 3},
+		{0, "verbose", 1},
+		{0, "debug", 1},
+		{'I', "", 1},
+		{'o', "output", 0},
+		{'\x01', "", 0},
+	}
+
+	for _, test := range tests {
+		if got := Count(results, test.char, test.name); got != test.want {
+			t.Errorf("Count(%q, %q) got %d, but wanted %d", test.char, test.name, got, test.want)
+		}
+	}
+}
+
+func TestFirstLast(t *testing.T) {
+	results := testParse(t, `prgm --level 1 -v --level=2 --lev 3`, testAggregateConfig())
+
+	first, found := First(results, 0, "level")
+	if !found || first.OptArg != "1" {
+		t.Errorf("First got %+v (found %t), but wanted OptArg %q", first, found, "1")
+	}
+
+	last, found := Last(results, 0, "level")
+	if !found || last.OptArg != "3" {
+		t.Errorf("Last got %+v (found %t), but wanted OptArg %q", last, found, "3")
+	}
+
+	if _, found := First(results, 'o', "output"); found {
+		t.Errorf("First found an option that wasn't given")
+	}
+	if _, found := Last(results, 'o', "output"); found {
+		t.Errorf("Last found an option that wasn't given")
+	}
+}
+
+func TestEvery(t *testing.T) {
+	results := testParse(t, `prgm -I a --include=b -v -Ic --inc d`, testAggregateConfig())
+
+	got := OptArgs(results, 'I', "include")
+	want := []string{"a", "b", "c", "d"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %+q, but wanted %+q", got, want)
+	}
+
+	if got := len(Every(results, 'v', "verbose")); got != 1 {
+		t.Errorf("got %d results, but wanted %d", got, 1)
+	}
+}
+
+func TestOptArgs(t *testing.T) {
+	c := testAggregateConfig()
+	c.LongOpts = append(c.LongOpts, LongOpt{Name: "point", NArgs: NArgs{Min: 2}})
+	results := testParse(t, `prgm --point 1 2 -v --point=3 4`, c)
+
+	got := OptArgs(results, 0, "point")
+	want := []string{"1", "2", "3", "4"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %+q, but wanted %+q", got, want)
+	}
+}
+
+func TestOnce(t *testing.T) {
+	t.Run("it returns a single option", func(t *testing.T) {
+		results := testParse(t, `prgm -v --output out`, testAggregateConfig())
+
+		res, found, err := Once(results, 'o', "output")
+		if err != nil {
+			t.Fatalf("got error %q, but didn't expect one", err)
+		}
+		if !found || res.OptArg != "out" {
+			t.Errorf("got %+v (found %t), but wanted OptArg %q", res, found, "out")
+		}
+	})
+
+	t.Run("it returns an absent option", func(t *testing.T) {
+		results := testParse(t, `prgm -v`, testAggregateConfig())
+
+		_, found, err := Once(results, 'o', "output")
+		if err != nil {
+			t.Fatalf("got error %q, but didn't expect one", err)
+		}
+		if found {
+			t.Errorf("found an option that wasn't given")
+		}
+	})
+
+	t.Run("it names both occurrences", func(t *testing.T) {
+		results := testParse(t, `prgm -o a -v --out=b -o c`, testAggregateConfig())
+
+		_, _, err := Once(results, 'o', "output")
+		if !errors.Is(err, ErrRepeatedOpt) {
+			t.Fatalf("got error %v, but wanted %v", err, ErrRepeatedOpt)
+		}
+
+		var repeatErr *RepeatError
+		if !errors.As(err, &repeatErr) {
+			t.Fatalf("got error %T, but wanted %T", err, repeatErr)
+		}
+		if repeatErr.First.OptArg != "a" || repeatErr.Second.OptArg != "b" {
+			t.Errorf("got occurrences %+v and %+v", repeatErr.First, repeatErr.Second)
+		}
+
+		want := "getopt: option given more than once: -o (argument 1) and --out (argument 4)"
+		if err.Error() != want {
+			t.Errorf("got message %q, but wanted %q", err.Error(), want)
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package getopt
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// The functions in this file emulate getopt, getopt_long and getopt_long_only
+// from OpenBSD's getopt_long.c (built with REPLACE_GETOPT), from which the
+// FreeBSD, NetBSD and macOS implementations are derived. It is used under the
+// ISC and BSD licenses:
+// Copyright (c) 2002 Todd C. Miller <millert@openbsd.org>
+// Copyright (c) 2000 The NetBSD Foundation, Inc.
+//
+// Unlike GNU libc, getopt never permutes arguments, skipped parameters are
+// permuted in blocks only once the next option (or the end of the arguments)
+// is reached, ambiguous long option abbreviations are always reported in
+// getopt_long_only, and long options are also matched within a group of short
+// options. [State.Reset] has the effect of setting optreset.
+
+func (s *State) getOptBSD(c Config) (res Result, err error) {
+	permute := c.Func != FuncGetOpt && c.Mode == ModeGNU
+	_, dashOpt := findOpt('-', c)
+
+	if s.argInd == 0 {
+		for {
+			if s.optInd >= len(s.args) {
+				if s.nonOptEnd != initNonOpt {
+					s.permuteBSD(s.nonOptStart, s.nonOptEnd, s.optInd)
+					s.optInd -= s.nonOptEnd - s.nonOptStart
+				} else if s.nonOptStart != initNonOpt {
+					s.optInd = s.nonOptStart
+				}
+				s.nonOptStart, s.nonOptEnd = initNonOpt, initNonOpt
+				return s.finish(DoneEnd)
+			}
+
+			s.done = DoneNone
+			s.termInd = initTermInd
+
+			arg := s.args[s.optInd]
+			if arg != "" && arg[0] == '-' && (arg != "-" || dashOpt) {
+				break
+			}
+			if c.Mode == ModeInOrder {
+				s.optInd++
+				return Result{Kind: KindParam, Char: '\x01', OptArg: arg, HasOptArg: true, Ind: s.optInd - 1}, nil
+			}
+			if !permute {
+				return s.finish(DoneParam)
+			}
+			if s.nonOptStart == initNonOpt {
+				s.nonOptStart = s.optInd
+			} else if s.nonOptEnd != initNonOpt {
+				s.permuteBSD(s.nonOptStart, s.nonOptEnd, s.optInd)
+				s.nonOptStart = s.optInd - (s.nonOptEnd - s.nonOptStart)
+				s.nonOptEnd = initNonOpt
+			}
+			s.optInd++
+		}
+
+		if s.nonOptStart != initNonOpt && s.nonOptEnd == initNonOpt {
+			s.nonOptEnd = s.optInd
+		}
+
+		if s.args[s.optInd] == "--" {
+			res, err = s.terminate(c)
+			if s.nonOptEnd != initNonOpt {
+				s.permuteBSD(s.nonOptStart, s.nonOptEnd, s.optInd)
+				s.optInd -= s.nonOptEnd - s.nonOptStart
+			}
+			s.nonOptStart, s.nonOptEnd = initNonOpt, initNonOpt
+			return res, err
+		}
+
+		if s.args[s.optInd] != "-" {
+			s.argInd = 1
+		}
+	} else {
+		s.done = DoneNone
+		s.termInd = initTermInd
+	}
+
+	ind, arg := s.optInd, s.args[s.optInd]
+	res, err = s.readOptBSD(c)
+	return describe(c, arg, ind, res, err)
+}
+
+func (s *State) readOptBSD(c Config) (res Result, err error) {
+	arg := s.args[s.optInd]
+	if c.Func != FuncGetOpt && s.argInd > 0 && (arg[s.argInd] == '-' || c.Func == FuncGetOptLongOnly) {
+		name := arg[s.argInd:]
+		shortToo := false
+		if name[0] == '-' {
+			name = name[1:]
+		} else if char, _ := utf8.DecodeRuneInString(name); char != ':' {
+			_, shortToo = findOpt(char, c)
+		}
+		if res, found, err := s.readLongOptBSD(name, shortToo, c); found {
+			s.argInd = 0
+			return res, err
+		}
+	}
+
+	offset := s.argInd
+	char, size := utf8.DecodeRuneInString(arg[s.argInd:])
+	s.argInd += size
+	rest := arg[s.argInd:]
+	res = Result{Kind: KindShortOpt, Char: char, offset: offset}
+
+	opt, found := findOpt(char, c)
+	if char == ':' || char == '-' && rest != "" || !found {
+		if char == '-' && rest == "" {
+			s.argInd = 0
+			return s.finish(DoneParam)
+		}
+		if rest == "" {
+			s.optInd++
+			s.argInd = 0
+		}
+		return res, ErrUnknownOpt
+	}
+
+	if opt.HasArg == NoArgument {
+		if rest == "" {
+			s.optInd++
+			s.argInd = 0
+		}
+		return res, nil
+	}
+
+	s.argInd = 0
+	if rest != "" {
+		res.OptArg, res.HasOptArg = rest, true
+	} else if opt.HasArg == RequiredArgument {
+		s.optInd++
+		if s.optInd >= len(s.args) {
+			return res, ErrMissingOptArg
+		}
+		res.OptArg, res.HasOptArg = s.args[s.optInd], true
+	}
+	s.optInd++
+	return res, nil
+}
+
+// readLongOptBSD parses name as a long option. If shortToo is set and name does
+// not match a long option, found is false so it can be parsed as a short
+// option instead.
+func (s *State) readLongOptBSD(name string, shortToo bool, c Config) (res Result, found bool, err error) {
+	s.optInd++
+	name, inline, hasInline := strings.Cut(name, "=")
+
+	match, exact, ambiguous := -1, false, false
+	for i, lo := range c.LongOpts {
+		if !strings.HasPrefix(lo.Name, name) {
+			continue
+		}
+		if lo.Name == name {
+			match, exact = i, true
+			break
+		}
+		if shortToo && utf8.RuneCountInString(name) == 1 {
+			continue
+		}
+		if match == -1 {
+			match = i
+		} else if c.Func == FuncGetOptLongOnly || lo.HasArg != c.LongOpts[match].HasArg {
+			ambiguous = true
+		}
+	}
+
+	if !exact && ambiguous {
+		return Result{Kind: KindLongOpt, Name: name}, true, ErrUnknownOpt
+	}
+
+	if match == -1 {
+		if shortToo {
+			s.optInd--
+			return res, false, nil
+		}
+		return Result{Kind: KindLongOpt, Name: name}, true, ErrUnknownOpt
+	}
+
+	opt := c.LongOpts[match]
+	res = Result{Kind: KindLongOpt, Char: opt.Char, Name: opt.Name}
+	switch {
+	case hasInline:
+		res.OptArg, res.HasOptArg = inline, true
+		if opt.HasArg == NoArgument {
+			return res, true, ErrIllegalOptArg
+		}
+	case opt.HasArg == RequiredArgument:
+		if s.optInd >= len(s.args) {
+			return res, true, ErrMissingOptArg
+		}
+		res.OptArg, res.HasOptArg = s.args[s.optInd], true
+		s.optInd++
+	}
+	return res, true, nil
+}
+
+// permuteBSD exchanges the block of skipped parameters from start to end with
+// the block of options from end to optEnd, keeping the order of each block.
+func (s *State) permuteBSD(start, end, optEnd int) {
+	slices.Reverse(s.args[start:end])
+	slices.Reverse(s.args[end:optEnd])
+	slices.Reverse(s.args[start:optEnd])
+}
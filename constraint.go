@@ -0,0 +1,132 @@
+package getopt
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Errors that can be returned when checking constraints.
+var (
+	ErrRequiredOpt    = errors.New("getopt: missing required option")
+	ErrConflictingOpt = errors.New("getopt: conflicting options")
+	ErrDependentOpt   = errors.New("getopt: option requires another option")
+)
+
+// An OptKey identifies an option by its short option character and long option
+// name, so results from either alias are unified. Either may be zero if the
+// option has no such alias.
+type OptKey struct {
+	Char rune   // short option character
+	Name string // long option name
+}
+
+// String returns the option's aliases (e.g., -o/--output).
+func (k OptKey) String() string {
+	var aliases []string
+	if k.Char != 0 {
+		aliases = append(aliases, "-"+string(k.Char))
+	}
+	if k.Name != "" {
+		aliases = append(aliases, "--"+k.Name)
+	}
+	return strings.Join(aliases, "/")
+}
+
+// A Constraint is a rule checked against parse results by [Check].
+type Constraint func(results []Result) error
+
+// A ConstraintError describes a violated [Constraint]. Options that were given
+// are named as they were typed.
+type ConstraintError struct {
+	Err     error    // the violated rule (e.g., ErrConflictingOpt)
+	Given   []Result // the first occurrence of each option given
+	Missing []OptKey // the options that were not given
+}
+
+func (e *ConstraintError) Error() string {
+	given := make([]string, len(e.Given))
+	for i, res := range e.Given {
+		given[i] = fmt.Sprintf("%s (argument %d)", res.Text, res.Ind)
+	}
+	missing := make([]string, len(e.Missing))
+	for i, key := range e.Missing {
+		missing[i] = key.String()
+	}
+
+	switch {
+	case len(given) > 0 && len(missing) > 0:
+		return fmt.Sprintf("%v: %s requires %s", e.Err, strings.Join(given, ", "), strings.Join(missing, ", "))
+	case len(given) > 0:
+		return fmt.Sprintf("%v: %s", e.Err, strings.Join(given, ", "))
+	default:
+		return fmt.Sprintf("%v: %s", e.Err, strings.Join(missing, ", "))
+	}
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// Required returns a [Constraint] that opt must be given.
+func Required(opt OptKey) Constraint {
+	return func(results []Result) error {
+		if _, found := First(results, opt.Char, opt.Name); !found {
+			return &ConstraintError{Err: ErrRequiredOpt, Missing: []OptKey{opt}}
+		}
+		return nil
+	}
+}
+
+// Exclusive returns a [Constraint] that at most one of opts is given. An option
+// may still be given more than once.
+func Exclusive(opts ...OptKey) Constraint {
+	return func(results []Result) error {
+		var given []Result
+		for _, opt := range opts {
+			if res, found := First(results, opt.Char, opt.Name); found {
+				given = append(given, res)
+			}
+		}
+		if len(given) > 1 {
+			slices.SortFunc(given, func(a, b Result) int { return cmp.Compare(a.Ind, b.Ind) })
+			return &ConstraintError{Err: ErrConflictingOpt, Given: given}
+		}
+		return nil
+	}
+}
+
+// Depends returns a [Constraint] that if opt is given, each of deps is also
+// given.
+func Depends(opt OptKey, deps ...OptKey) Constraint {
+	return func(results []Result) error {
+		res, found := First(results, opt.Char, opt.Name)
+		if !found {
+			return nil
+		}
+		var missing []OptKey
+		for _, dep := range deps {
+			if _, found := First(results, dep.Char, dep.Name); !found {
+				missing = append(missing, dep)
+			}
+		}
+		if len(missing) > 0 {
+			return &ConstraintError{Err: ErrDependentOpt, Given: []Result{res}, Missing: missing}
+		}
+		return nil
+	}
+}
+
+// Check checks results against each of constraints, returning every violation
+// joined by [errors.Join], or nil if there are none.
+func Check(results []Result, constraints ...Constraint) error {
+	var errs []error
+	for _, constraint := range constraints {
+		if err := constraint(results); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
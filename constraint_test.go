@@ -0,0 +1,125 @@
+package getopt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var (
+	keyOutput  = OptKey{Char: 'o', Name: "output"}
+	keyJSON    = OptKey{Name: "json"}
+	keyTable   = OptKey{Char: 't', Name: "table"}
+	keyKey     = OptKey{Name: "key"}
+	keyCert    = OptKey{Name: "cert"}
+	keyVerbose = OptKey{Char: 'v'}
+)
+
+// testConstraintConfig returns a Config with the options used by constraints in
+// tests.
+func testConstraintConfig() Config {
+	return Config{
+		Opts:     OptStr(`o:tv`),
+		LongOpts: LongOptStr(`output:,json,table,key:,cert:`),
+		Func:     FuncGetOptLong,
+	}
+}
+
+func TestOptKey(t *testing.T) {
+	tests := []struct {
+		key  OptKey
+		want string
+	}{
+		{keyOutput, "-o/--output"},
+		{keyJSON, "--json"},
+		{keyVerbose, "-v"},
+	}
+
+	for _, test := range tests {
+		if got := test.key.String(); got != test.want {
+			t.Errorf("got %q, but wanted %q", got, test.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	constraints := []Constraint{
+		Required(keyOutput),
+		Exclusive(keyJSON, keyTable),
+		Depends(keyKey, keyCert),
+	}
+
+	tests := []struct {
+		label string
+		args  string
+		want  []string
+	}{
+		{
+			label: "no violations",
+			args:  `prgm --out=a --json --key k --cert c`,
+		},
+		{
+			label: "required",
+			args:  `prgm -v`,
+			want:  []string{"getopt: missing required option: -o/--output"},
+		},
+		{
+			label: "exclusive",
+			args:  `prgm -o a --tab --js --json`,
+			want:  []string{"getopt: conflicting options: --tab (argument 3), --js (argument 4)"},
+		},
+		{
+			label: "exclusive with repeated option",
+			args:  `prgm -o a --json --json`,
+		},
+		{
+			label: "depends",
+			args:  `prgm -o a --ke=k`,
+			want:  []string{"getopt: option requires another option: --ke (argument 3) requires --cert"},
+		},
+		{
+			label: "all violations",
+			args:  `prgm -t --key k --json`,
+			want: []string{
+				"getopt: missing required option: -o/--output",
+				"getopt: conflicting options: -t (argument 1), --json (argument 4)",
+				"getopt: option requires another option: --key (argument 2) requires --cert",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.label, func(t *testing.T) {
+			err := Check(testParse(t, test.args, testConstraintConfig()), constraints...)
+
+			if len(test.want) == 0 {
+				if err != nil {
+					t.Fatalf("got error %q, but didn't expect one", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("wanted an error, but didn't get one")
+			}
+			if got, want := err.Error(), strings.Join(test.want, "\n"); got != want {
+				t.Errorf("got errors %q, but wanted %q", got, want)
+			}
+		})
+	}
+}
+
+func TestConstraintError(t *testing.T) {
+	err := Check(testParse(t, `prgm --json -t`, testConstraintConfig()), Exclusive(keyJSON, keyTable), Required(keyOutput))
+
+	if !errors.Is(err, ErrConflictingOpt) || !errors.Is(err, ErrRequiredOpt) {
+		t.Errorf("got error %q, but wanted it to match %q and %q", err, ErrConflictingOpt, ErrRequiredOpt)
+	}
+
+	var constraintErr *ConstraintError
+	if !errors.As(err, &constraintErr) {
+		t.Fatalf("got error %T, but wanted %T", err, constraintErr)
+	}
+	if len(constraintErr.Given) != 2 || constraintErr.Given[0].Name != "json" || constraintErr.Given[1].Char != 't' {
+		t.Errorf("got Given %+v", constraintErr.Given)
+	}
+}
@@ -0,0 +1,144 @@
+package getopt
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"unicode/utf8"
+)
+
+// boolFlag matches the optional interface implemented by boolean [flag.Value]
+// types, which the flag package allows to be set without an argument.
+type boolFlag interface {
+	flag.Value
+	IsBoolFlag() bool
+}
+
+// FlagConfig returns a [Config] with rules for each flag defined in fs, for
+// parsing with [FuncGetOptLongOnly].
+//
+// Boolean flags do not allow arguments ([NoArgument]), and all other flags
+// require an argument ([RequiredArgument]). Every flag is a long option, and
+// flags with single-character names are also short options, so they can be
+// grouped (e.g., -vx).
+func FlagConfig(fs *flag.FlagSet) Config {
+	c := Config{Func: FuncGetOptLongOnly}
+	fs.VisitAll(func(f *flag.Flag) {
+		hasArg := RequiredArgument
+		if isBoolFlag(f) {
+			hasArg = NoArgument
+		}
+		if char, size := utf8.DecodeRuneInString(f.Name); size == len(f.Name) {
+			c.Opts = append(c.Opts, Opt{Char: char, HasArg: hasArg})
+		}
+		c.LongOpts = append(c.LongOpts, LongOpt{Name: f.Name, HasArg: hasArg})
+	})
+	return c
+}
+
+// SetFlags sets the value of the flag in fs for each of results, by calling
+// [flag.FlagSet.Set]. Boolean flags without an argument are set to "true", or
+// "false" if [Result.Negated] is set.
+//
+// Results that do not refer to a flag defined in fs are ignored.
+func SetFlags(fs *flag.FlagSet, results []Result) error {
+	for _, res := range results {
+		name := res.Name
+		if name == "" {
+			if res.Char == 0 {
+				continue
+			}
+			name = string(res.Char)
+		}
+		f := fs.Lookup(name)
+		if f == nil {
+			continue
+		}
+		value := res.OptArg
+		if isBoolFlag(f) && res.Negated {
+			value = "false"
+		} else if isBoolFlag(f) && !res.HasOptArg {
+			value = "true"
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("getopt: invalid value %q for flag %s: %w", value, name, err)
+		}
+	}
+	return nil
+}
+
+// ParseFlags parses args using the flags defined in fs, and sets their values.
+// Like [NewState], parsing starts with the element at index 1 of args. It
+// returns the parameter (non-option) arguments, which may have been permuted
+// from between options, and are also returned by [flag.FlagSet.Args].
+//
+// Errors are handled like [flag.FlagSet.Parse]: the error is printed to
+// [flag.FlagSet.Output] followed by the usage message, and the FlagSet's
+// [flag.ErrorHandling] decides whether the error is returned, or the program
+// exits or panics. Option errors are an [*OptError], so the message names the
+// option as typed. If -h, -help or --help is given but not defined, the error
+// is [flag.ErrHelp].
+func ParseFlags(fs *flag.FlagSet, args []string) (params []string, err error) {
+	s := NewState(args)
+	err = parseFlags(s, fs)
+	params = s.Params()
+	// parse the params after a terminator, so fs.Parsed and fs.Args are set
+	fs.Parse(append([]string{"--"}, params...))
+	if err == nil {
+		return params, nil
+	}
+
+	if err != flag.ErrHelp {
+		fmt.Fprintln(fs.Output(), err)
+	}
+	if fs.Usage != nil {
+		fs.Usage()
+	} else {
+		fmt.Fprintf(fs.Output(), "Usage of %s:\n", fs.Name())
+		fs.PrintDefaults()
+	}
+	switch fs.ErrorHandling() {
+	case flag.ExitOnError:
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	case flag.PanicOnError:
+		panic(err)
+	}
+	return params, err
+}
+
+// parseFlags parses the arguments in s using the flags defined in fs, and sets
+// their values.
+func parseFlags(s *State, fs *flag.FlagSet) error {
+	var results []Result
+	c := FlagConfig(fs)
+	c.DetailedErrors = true
+	for res, err := range s.All(c) {
+		if errors.Is(err, ErrUnknownOpt) && isHelp(res) {
+			return flag.ErrHelp
+		}
+		if err != nil {
+			return err
+		}
+		results = append(results, res)
+	}
+	return SetFlags(fs, results)
+}
+
+// isHelp reports whether res is an undefined -h or -help flag, which the flag
+// package reports as [flag.ErrHelp].
+func isHelp(res Result) bool {
+	name := res.Name
+	if name == "" {
+		name = string(res.Char)
+	}
+	return name == "h" || name == "help"
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(boolFlag)
+	return ok && b.IsBoolFlag()
+}
@@ -0,0 +1,175 @@
+package getopt
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFlagSet() (fs *flag.FlagSet, verbose, extract *bool, level *int, timeout *time.Duration) {
+	fs = flag.NewFlagSet("prgm", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	verbose = fs.Bool("v", false, "")
+	extract = fs.Bool("x", false, "")
+	level = fs.Int("level", 0, "")
+	timeout = fs.Duration("timeout", 0, "")
+	return fs, verbose, extract, level, timeout
+}
+
+func TestFlagConfig(t *testing.T) {
+	fs, _, _, _, _ := testFlagSet()
+	c := FlagConfig(fs)
+
+	if c.Func != FuncGetOptLongOnly {
+		t.Errorf("got Func %d, but wanted %d", c.Func, FuncGetOptLongOnly)
+	}
+
+	wantOpts := []Opt{{Char: 'v', HasArg: NoArgument}, {Char: 'x', HasArg: NoArgument}}
+	if !slices.Equal(c.Opts, wantOpts) {
+		t.Errorf("got Opts %+v, but wanted %+v", c.Opts, wantOpts)
+	}
+
+	wantLongOpts := []LongOpt{
+		{Name: "level", HasArg: RequiredArgument},
+		{Name: "timeout", HasArg: RequiredArgument},
+		{Name: "v", HasArg: NoArgument},
+		{Name: "x", HasArg: NoArgument},
+	}
+	if !slices.Equal(c.LongOpts, wantLongOpts) {
+		t.Errorf("got LongOpts %+v, but wanted %+v", c.LongOpts, wantLongOpts)
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	t.Run("it sets flag values", func(t *testing.T) {
+		fs, verbose, extract, level, timeout := testFlagSet()
+
+		params, err := ParseFlags(fs, argsStr(`prgm p1 -vx -level 3 p2 --timeout=5s`))
+		if err != nil {
+			t.Fatalf("got error %q, but didn't expect one", err)
+		}
+
+		if !*verbose || !*extract {
+			t.Errorf("got v %t and x %t, but wanted both set", *verbose, *extract)
+		}
+		if *level != 3 {
+			t.Errorf("got level %d, but wanted %d", *level, 3)
+		}
+		if *timeout != 5*time.Second {
+			t.Errorf("got timeout %v, but wanted %v", *timeout, 5*time.Second)
+		}
+
+		wantParams := []string{"p1", "p2"}
+		if !slices.Equal(params, wantParams) {
+			t.Errorf("got params %+q, but wanted %+q", params, wantParams)
+		}
+		if !fs.Parsed() {
+			t.Errorf("got Parsed false, but wanted true")
+		}
+		if !slices.Equal(fs.Args(), wantParams) {
+			t.Errorf("got Args %+q, but wanted %+q", fs.Args(), wantParams)
+		}
+	})
+
+	t.Run("it prints errors and usage", func(t *testing.T) {
+		fs, _, _, _, _ := testFlagSet()
+		var out strings.Builder
+		fs.SetOutput(&out)
+		fs.Usage = func() { out.WriteString("usage: prgm [-vx]\n") }
+
+		_, err := ParseFlags(fs, argsStr(`prgm -v -y p1`))
+		if !errors.Is(err, ErrUnknownOpt) {
+			t.Errorf("got error %v, but wanted %v", err, ErrUnknownOpt)
+		}
+
+		want := "getopt: unrecognized option: -y (did you mean --v?)\nusage: prgm [-vx]\n"
+		if out.String() != want {
+			t.Errorf("got output %q, but wanted %q", out.String(), want)
+		}
+	})
+
+	for _, arg := range []string{"-h", "-help", "--help"} {
+		t.Run("it returns help errors for "+arg, func(t *testing.T) {
+			fs, _, _, _, _ := testFlagSet()
+			var out strings.Builder
+			fs.SetOutput(&out)
+
+			_, err := ParseFlags(fs, []string{"prgm", arg})
+			if err != flag.ErrHelp {
+				t.Errorf("got error %v, but wanted %v", err, flag.ErrHelp)
+			}
+			if want := "Usage of prgm:\n"; !strings.HasPrefix(out.String(), want) {
+				t.Errorf("got output %q, but wanted prefix %q", out.String(), want)
+			}
+		})
+	}
+
+	t.Run("it panics with PanicOnError", func(t *testing.T) {
+		fs, _, _, _, _ := testFlagSet()
+		fs.Init("prgm", flag.PanicOnError)
+
+		defer func() {
+			if err, _ := recover().(error); !errors.Is(err, ErrUnknownOpt) {
+				t.Errorf("got panic %v, but wanted %v", err, ErrUnknownOpt)
+			}
+		}()
+		ParseFlags(fs, argsStr(`prgm -y`))
+	})
+
+	t.Run("it returns parsing errors", func(t *testing.T) {
+		fs, _, _, _, _ := testFlagSet()
+
+		_, err := ParseFlags(fs, argsStr(`prgm -v -level`))
+		if !errors.Is(err, ErrMissingOptArg) {
+			t.Errorf("got error %v, but wanted %v", err, ErrMissingOptArg)
+		}
+	})
+
+	t.Run("it returns flag value errors", func(t *testing.T) {
+		fs, _, _, level, _ := testFlagSet()
+
+		_, err := ParseFlags(fs, argsStr(`prgm -level three`))
+		if err == nil {
+			t.Fatalf("got no error, but wanted one")
+		}
+		if *level != 0 {
+			t.Errorf("got level %d, but wanted %d", *level, 0)
+		}
+	})
+}
+
+func TestSetFlags(t *testing.T) {
+	fs, verbose, extract, level, _ := testFlagSet()
+	*extract = true
+
+	err := SetFlags(fs, []Result{
+		{Kind: KindShortOpt, Char: 'v'},
+		{Kind: KindLongOpt, Name: "x", Negated: true},
+		{Kind: KindLongOpt, Name: "level", OptArg: "1"},
+		{Kind: KindLongOpt, Name: "level", OptArg: "2"},
+		{Kind: KindParam, Char: '\x01', OptArg: "p1"},
+	})
+	if err != nil {
+		t.Fatalf("got error %q, but didn't expect one", err)
+	}
+
+	if !*verbose {
+		t.Errorf("got v %t, but wanted %t", *verbose, true)
+	}
+	if *extract {
+		t.Errorf("got x %t, but wanted %t", *extract, false)
+	}
+	if *level != 2 {
+		t.Errorf("got level %d, but wanted %d", *level, 2)
+	}
+
+	var visited []string
+	fs.Visit(func(f *flag.Flag) { visited = append(visited, f.Name) })
+	if want := []string{"level", "v", "x"}; !slices.Equal(visited, want) {
+		t.Errorf("got visited flags %+q, but wanted %+q", visited, want)
+	}
+}
@@ -7,10 +7,14 @@ options.
 package getopt
 
 import (
+	"cmp"
 	"errors"
+	"fmt"
 	"iter"
 	"slices"
+	"strconv"
 	"strings"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -20,8 +24,41 @@ var (
 	ErrUnknownOpt    = errors.New("getopt: unrecognized option")
 	ErrIllegalOptArg = errors.New("getopt: option disallows arguments")
 	ErrMissingOptArg = errors.New("getopt: option requires an argument")
+
+	ErrAttachedOptArg = errors.New("getopt: option disallows attached arguments")
+	ErrSeparateOptArg = errors.New("getopt: option requires an attached argument")
+	ErrBundledOpt     = errors.New("getopt: option may not be grouped")
 )
 
+// An OptError describes an invalid option, and is returned instead of the
+// underlying error if enabled by [Config.DetailedErrors].
+type OptError struct {
+	Err        error  // the underlying error (e.g., ErrUnknownOpt)
+	Result     Result // the invalid option, as returned with the error
+	Suggestion string // a similar valid option, as it could be typed (e.g., --verbose)
+	MinArgs    int    // the minimum number of arguments, for an option with NArgs
+	Expected   string // the expected form of the option's argument (e.g., --opt=value)
+	Offset     int    // byte offset of a short option (e.g., 3 for X in -abXc)
+}
+
+func (e *OptError) Error() string {
+	msg := fmt.Sprintf("%v: %s", e.Err, e.Result.Text)
+	if e.MinArgs > 1 {
+		msg += fmt.Sprintf(" (requires %d arguments, got %d)", e.MinArgs, len(e.Result.OptArgs()))
+	}
+	if e.Suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %s?)", e.Suggestion)
+	}
+	if e.Expected != "" {
+		msg += fmt.Sprintf(" (expected %s)", e.Expected)
+	}
+	return msg
+}
+
+func (e *OptError) Unwrap() error {
+	return e.Err
+}
+
 // HasArg defines rules for parsing option arguments.
 type HasArg int
 
@@ -31,6 +68,26 @@ const (
 	OptionalArgument               // option may optionally accept an argument
 )
 
+// Attach defines rules for where an option argument may be given.
+type Attach int
+
+const (
+	AttachDefault  Attach = iota // use the rule from Config (AttachAny, if unset)
+	AttachAny                    // argument may be attached or in the next word
+	AttachJoined                 // argument must be attached (e.g., --opt=value)
+	AttachSeparate               // argument must be separate (e.g., --opt value)
+)
+
+// Bundle defines rules for grouping short options in one word (e.g., -abc).
+type Bundle int
+
+const (
+	BundleAny   Bundle = iota // any short options may be grouped (e.g., -ab value)
+	BundleNoArg               // only options without arguments may be grouped
+	BundleNone                // short options may not be grouped
+	BundleLong                // like BundleNone, but a group may be a long option
+)
+
 // Func indicates which POSIX or GNU extension function to emulate during option
 // parsing.
 type Func int
@@ -50,11 +107,72 @@ const (
 	ModeInOrder             // enable "in-order" behavior (parse parameters as options)
 )
 
+// Libc indicates which C library implementation of getopt to emulate during
+// option parsing.
+type Libc int
+
+const (
+	LibcGNU  Libc = iota // emulate GNU libc
+	LibcMusl             // emulate musl libc
+	LibcBSD              // emulate BSD libc (OpenBSD getopt_long.c)
+)
+
+// Abbrev indicates which abbreviations of long option names to accept during
+// option parsing.
+type Abbrev int
+
+const (
+	AbbrevPrefix Abbrev = iota // accept unique prefixes (GNU libc behavior)
+	AbbrevExact                // accept only exact names
+)
+
+// NArgs defines rules for parsing options that take more than one argument
+// (e.g., --point X Y), from the words following the option. An argument
+// attached to the option (e.g., --point=X Y) is the first. The first Min words
+// are always taken, and further words are taken up to Max, until a word that
+// begins an option or the "--" terminator. If Max is less than Min, exactly Min
+// arguments are taken. Arguments are only parsed this way when emulating
+// [LibcGNU].
+//
+// With Rest or Until, the arguments are taken as they are, even if they begin
+// options, and are never permuted.
+type NArgs struct {
+	Min int // minimum number of arguments
+	Max int // maximum number of arguments
+
+	// Rest enables taking all remaining words (e.g., xterm -e prog args...).
+	Rest bool
+
+	// Until sets a word that ends the arguments, which is consumed but not
+	// taken (e.g., ";" for find -exec cmd {} ;). It is an error if the word is
+	// missing, unless Rest is also set.
+	Until string
+}
+
+// limit returns the maximum number of arguments, or 0 if n is unset.
+func (n NArgs) limit() int {
+	return max(n.Min, n.Max)
+}
+
+// verbatim reports whether words are taken without regard to options.
+func (n NArgs) verbatim() bool {
+	return n.Rest || n.Until != ""
+}
+
 // An Opt is a parsing rule for a short, single-character command-line option
 // (e.g., -a).
 type Opt struct {
 	Char   rune   // option character
 	HasArg HasArg // option argument rule
+	NArgs  NArgs  // multiple argument rule, which overrides HasArg if set
+
+	// AllowOptLikeArg enables taking a required argument that looks like an
+	// option with Config.StrictRequiredArg (e.g., -5 for -n).
+	AllowOptLikeArg bool
+
+	// Attach sets where the option's argument may be given, overriding
+	// Config.ShortAttach.
+	Attach Attach
 }
 
 // OptStr parses an option string, returning a slice of Opt.
@@ -68,22 +186,30 @@ type Opt struct {
 func OptStr(optStr string) (opts []Opt) {
 	var i int
 	for i < len(optStr) {
-		char, size := utf8.DecodeRuneInString(optStr[i:])
-		i += size
+		var opt Opt
+		opt, i = readOptStr(optStr, i)
+		opts = append(opts, opt)
+	}
 
-		hasArg := NoArgument
+	return opts
+}
+
+// readOptStr parses the option character at index i of optStr and its colons,
+// returning the option and the index following it.
+func readOptStr(optStr string, i int) (opt Opt, next int) {
+	char, size := utf8.DecodeRuneInString(optStr[i:])
+	i += size
+
+	hasArg := NoArgument
+	if i < len(optStr) && optStr[i] == ':' {
+		hasArg = RequiredArgument
+		i++
 		if i < len(optStr) && optStr[i] == ':' {
-			hasArg = RequiredArgument
+			hasArg = OptionalArgument
 			i++
-			if i < len(optStr) && optStr[i] == ':' {
-				hasArg = OptionalArgument
-				i++
-			}
 		}
-		opts = append(opts, Opt{Char: char, HasArg: hasArg})
 	}
-
-	return opts
+	return Opt{Char: char, HasArg: hasArg}, i
 }
 
 // A LongOpt is a parsing rule for a named, long command-line option
@@ -91,14 +217,48 @@ func OptStr(optStr string) (opts []Opt) {
 type LongOpt struct {
 	Name   string // option name
 	HasArg HasArg // option argument rule
+	Char   rune   // linked short option character (optional)
+	NArgs  NArgs  // multiple argument rule, which overrides HasArg if set
+
+	// Negatable enables a negated form of the option, with the name prefixed by
+	// "no-" (e.g., --no-color for color). The negated form does not allow
+	// arguments, and is reported with Result.Negated. An abbreviation only
+	// matches a negated form if "no-" is typed in full and the abbreviation is
+	// unambiguous. Negated forms are not parsed when emulating other than
+	// [LibcGNU].
+	Negatable bool
+
+	// AllowOptLikeArg enables taking a required argument that looks like an
+	// option with Config.StrictRequiredArg (e.g., -5 for --lines).
+	AllowOptLikeArg bool
+
+	// Attach sets where the option's argument may be given, overriding
+	// Config.LongAttach.
+	Attach Attach
+
+	// NoAbbrev disables matching abbreviations of the option, so it must be
+	// typed in full.
+	NoAbbrev bool
+
+	// StableAbbrev is the shortest abbreviation that always matches the option,
+	// even if it is also a prefix of another option (e.g., "ver" for verbose,
+	// so --ver keeps its meaning when a version option is added). Any longer
+	// prefix of the name also matches. It is accepted regardless of
+	// [Config.Abbrev] and [Config.MinAbbrev].
+	StableAbbrev string
 }
 
+const negPrefix = "no-"
+
 // OptStr parses a long option string, returning a slice of LongOpt.
 //
 // The option string uses the same format as --longoptions in the GNU
 // [getopt(1)] command. Option names are comma-separated, and argument rules are
 // designated by colon suffixes, like with [OptStr].
 //
+// As an extension, names with a "[no-]" prefix (e.g., "[no-]verify") are
+// [LongOpt.Negatable].
+//
 // [getopt(1)]: https://www.man7.org/linux/man-pages/man1/getopt.1.html
 func LongOptStr(longOptStr string) (longOpts []LongOpt) {
 	items := strings.Split(longOptStr, ",")
@@ -108,9 +268,11 @@ func LongOptStr(longOptStr string) (longOpts []LongOpt) {
 	for _, item := range items {
 		var opt LongOpt
 		opt.Name = strings.TrimRight(item, ":")
-		if len(opt.Name) == len(item)-1 {
+		suffixLen := len(item) - len(opt.Name)
+		opt.Name, opt.Negatable = strings.CutPrefix(opt.Name, "["+negPrefix+"]")
+		if suffixLen == 1 {
 			opt.HasArg = RequiredArgument
-		} else if len(opt.Name) == len(item)-2 {
+		} else if suffixLen == 2 {
 			opt.HasArg = OptionalArgument
 		}
 
@@ -120,40 +282,219 @@ func LongOptStr(longOptStr string) (longOpts []LongOpt) {
 	return longOpts
 }
 
+// SolarisOptStr parses an option string with embedded long option names,
+// returning a slice of Opt and a slice of LongOpt linked to them.
+//
+// The option string uses the same format as the Solaris [getopt(3C)], which
+// extends [OptStr] with long option names in parentheses following each option
+// character and its colons (e.g., "a(all)b:(bytes)"). An option may have more
+// than one long name (e.g., "f:(file)(input)"). Each LongOpt has the same
+// argument rule as its short option, and its Char is set to the short option's
+// character, so --all is reported like -a.
+//
+// Unlike Solaris getopt, long options are only parsed when [Config.Func] is
+// [FuncGetOptLong] or [FuncGetOptLongOnly]. With the default [FuncGetOpt],
+// the returned LongOpts are ignored.
+//
+// [getopt(3C)]: https://illumos.org/man/3C/getopt
+func SolarisOptStr(optStr string) (opts []Opt, longOpts []LongOpt) {
+	var i int
+	for i < len(optStr) {
+		var opt Opt
+		opt, i = readOptStr(optStr, i)
+		opts = append(opts, opt)
+
+		for i < len(optStr) && optStr[i] == '(' {
+			name, rest, _ := strings.Cut(optStr[i+1:], ")")
+			if name != "" {
+				longOpts = append(longOpts, LongOpt{Name: name, HasArg: opt.HasArg, Char: opt.Char})
+			}
+			i = len(optStr) - len(rest)
+		}
+	}
+
+	return opts, longOpts
+}
+
 // A Config defines the rules and behavior used when parsing options. Note the
-// zero values for Func ([FuncGetOpt]) and Mode ([ModeGNU]), which will
-// determine the parsing behavior unless set otherwise.
+// zero values for Func ([FuncGetOpt]), Mode ([ModeGNU]) and Libc ([LibcGNU]),
+// which will determine the parsing behavior unless set otherwise.
 type Config struct {
 	Opts     []Opt     // allowed short options
 	LongOpts []LongOpt // allowed long options
 	Func     Func      // parsing function
 	Mode     Mode      // parsing behavior
+	Libc     Libc      // emulated implementation
+
+	// YieldTerminator enables returning the "--" terminator as a [Result] of
+	// kind [KindTerminator], instead of completing with [ErrDone]. Parsing
+	// completes with ErrDone on the following call.
+	YieldTerminator bool
+
+	// DetailedErrors enables returning an [*OptError] for an invalid option,
+	// which describes the option as typed and its position, and suggests a
+	// similar valid option if it is unknown. It wraps the same errors, which
+	// can be matched using [errors.Is].
+	DetailedErrors bool
+
+	// Abbrev sets which abbreviations of long option names are accepted. The
+	// zero value ([AbbrevPrefix]) accepts unique prefixes, like GNU libc.
+	Abbrev Abbrev
+
+	// Prefixes sets the characters that begin an option, which defaults to "-"
+	// (e.g., "-+" to accept +x as well as -x, or "/" for DOS-style switches).
+	// Options may be grouped after any prefix, and doubling a prefix begins a
+	// long option (e.g., ++opt). A word consisting of a prefix character alone,
+	// or a doubled prefix character other than "-", is a parameter. The prefix
+	// an option was typed with is reported with Result.Prefix.
+	Prefixes string
+
+	// NumericOpts enables parsing a run of digits where a short option is
+	// expected as a single numeric option (e.g., -20 as in head -20), with the
+	// digits in Result.OptArg. Digits defined as short options are parsed as
+	// those options instead. A negative number is still taken as the argument
+	// of an option that requires one (e.g., -n -5).
+	NumericOpts bool
+
+	// Bundle sets which short options may be grouped in one word. By default,
+	// as in GNU libc, any may be. With BundleNoArg, an option that takes an
+	// argument may not be grouped with others (e.g., -ab value), and with
+	// BundleLong, a group is first parsed as a long option (e.g., -abc as
+	// --abc). A group that is not allowed returns ErrBundledOpt for the option
+	// grouped with the others. An option that takes an argument may still have
+	// it attached (e.g., -ovalue).
+	Bundle Bundle
+
+	// ShortAttach and LongAttach set where the arguments of short and long
+	// options may be given. By default, as in GNU libc, an argument may be
+	// attached or in the next word. With AttachJoined, a required argument that
+	// is not attached returns ErrSeparateOptArg (or ErrMissingOptArg if there is
+	// no next word), and the next word is not taken. With AttachSeparate, an
+	// attached argument returns ErrAttachedOptArg. An optional argument is
+	// taken from the next word only with SeparateOptArg.
+	ShortAttach Attach
+	LongAttach  Attach
+
+	// StrictRequiredArg enables returning ErrMissingOptArg for an option with a
+	// RequiredArgument if the next word begins an option or is the "--"
+	// terminator, instead of taking it as the argument (e.g., -o -v). The word
+	// is then parsed on its own. Opt.AllowOptLikeArg and
+	// LongOpt.AllowOptLikeArg override this for a single option.
+	StrictRequiredArg bool
+
+	// SeparateOptArg enables taking the argument of an option with an
+	// OptionalArgument from the next word, if none is attached and the word
+	// does not begin an option (e.g., --color always). By default, as in GNU
+	// libc, an optional argument must be attached (e.g., --color=always).
+	SeparateOptArg bool
+
+	// IsParam, if set, reports whether a word is a parameter, so it is not taken
+	// as an optional argument with SeparateOptArg (e.g., a file name).
+	IsParam func(word string) bool
+
+	// MinAbbrev sets the minimum length (in runes) of an abbreviated long
+	// option name, not counting the "no-" of a negated form. Shorter
+	// abbreviations are not matched.
+	MinAbbrev int
+
+	// FoldCase enables matching long option names and their abbreviations
+	// under simple Unicode case folding, like [strings.EqualFold] (e.g.,
+	// --Verbose for verbose). Short options remain case-sensitive.
+	FoldCase bool
+
+	// Normalize, if set, is applied to long option names and short option
+	// characters, both as defined and as typed, before they are compared (e.g.,
+	// norm.NFC.String from golang.org/x/text/unicode/norm, so --café matches
+	// whether it is typed precomposed or decomposed). A typed short option
+	// character is normalized together with any combining marks following it.
+	Normalize func(string) string
 }
 
+// Kind indicates which kind of argument a [Result] was parsed from.
+type Kind int
+
+const (
+	KindNone       Kind = iota // no argument was parsed
+	KindShortOpt               // short option (e.g., -a)
+	KindLongOpt                // long option (e.g., --option)
+	KindParam                  // parameter, parsed in ModeInOrder
+	KindTerminator             // "--" terminator, parsed with Config.YieldTerminator
+	KindNumericOpt             // numeric option (e.g., -20), parsed with Config.NumericOpts
+)
+
 type Result struct {
-	Char   rune   // parsed short option character
-	Name   string // parsed long option name
-	OptArg string // parsed option argument
+	Kind      Kind   // parsed argument kind
+	Char      rune   // parsed short option character (or linked LongOpt.Char)
+	Name      string // parsed long option name
+	OptArg    string // parsed option argument
+	HasOptArg bool   // whether an option argument was given, which may be empty
+	Negated   bool   // whether the negated form of a long option was parsed
+	Prefix    rune   // prefix character the option was typed with (e.g., '-')
+	Text      string // option as typed, excluding its argument (e.g., -a or --opt)
+	Ind       int    // index of the parsed argument, in the slice used to initialize State
+
+	// moreOptArgs holds the arguments following OptArg, for an option with
+	// NArgs. Each is preceded by its length and a colon, so that arguments
+	// can contain any byte and Result remains comparable.
+	moreOptArgs string
+	offset      int // byte offset of Char in the argument at Ind, until passed to describe
 }
 
+// OptArgs returns all arguments parsed for the option, which for an option
+// with [NArgs] may be more than one.
+func (res Result) OptArgs() (optArgs []string) {
+	if !res.HasOptArg {
+		return nil
+	}
+	optArgs = append(optArgs, res.OptArg)
+	for rest := res.moreOptArgs; rest != ""; {
+		size, tail, _ := strings.Cut(rest, ":")
+		n, _ := strconv.Atoi(size)
+		optArgs = append(optArgs, tail[:n])
+		rest = tail[n:]
+	}
+	return optArgs
+}
+
+// DoneReason indicates why option parsing completed.
+type DoneReason int
+
+const (
+	DoneNone       DoneReason = iota // parsing has not completed
+	DoneEnd                          // the end of the arguments was reached
+	DoneTerminator                   // the "--" terminator was consumed
+	DoneParam                        // a parameter was reached without permuting (e.g., in ModePOSIX)
+)
+
 type State struct {
-	args   []string // current argument slice
-	optInd int      // next argument to process
-	argInd int      // next index of the current argument to process (when processing a short group)
+	args       []string   // current argument slice
+	optInd     int        // next argument to process
+	argInd     int        // next index of the current argument to process (when processing a short group)
+	terminated bool       // whether the "--" terminator was returned as a result
+	done       DoneReason // why parsing completed
+	termInd    int        // original index of the consumed "--" terminator
+
+	nonOptStart int // first skipped parameter awaiting permutation (LibcBSD)
+	nonOptEnd   int // first option after the skipped parameters (LibcBSD)
 }
 
 const (
-	initOptInd = 1
-	initArgInd = 0
+	initOptInd  = 1
+	initArgInd  = 0
+	initTermInd = -1
+	initNonOpt  = -1
 )
 
 // NewState returns a new [State] to parse options from args, starting with the
 // element at index 1.
 func NewState(args []string) *State {
 	s := &State{
-		args:   args,
-		optInd: initOptInd,
-		argInd: initArgInd,
+		args:        args,
+		optInd:      initOptInd,
+		argInd:      initArgInd,
+		termInd:     initTermInd,
+		nonOptStart: initNonOpt,
+		nonOptEnd:   initNonOpt,
 	}
 	return s
 }
@@ -174,6 +515,24 @@ func (s *State) Parse(c Config) ([]Result, error) {
 	return results, nil
 }
 
+// ParseAll is like [State.Parse], but continues past invalid options, and
+// returns the errors for all of them joined with [errors.Join]. Each error is
+// an [*OptError] (as if enabled by [Config.DetailedErrors]), which holds the
+// position of the option.
+func (s *State) ParseAll(c Config) ([]Result, error) {
+	c.DetailedErrors = true
+	results := []Result{}
+	var errs []error
+	for res, err := range s.All(c) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		results = append(results, res)
+	}
+	return results, errors.Join(errs...)
+}
+
 // All returns an iterator that yields successive parsing results.
 func (s *State) All(c Config) iter.Seq2[Result, error] {
 	return func(yield func(Result, error) bool) {
@@ -217,12 +576,50 @@ func (s *State) Params() []string {
 	return s.args[s.optInd:]
 }
 
+// Done returns the reason that parsing completed, or [DoneNone] if parsing has
+// not completed.
+func (s *State) Done() DoneReason {
+	return s.done
+}
+
+// TermInd returns the index of the consumed "--" terminator in the slice used
+// to initialize [State], or -1 if parsing did not complete with [DoneTerminator].
+//
+// The index refers to the original argument order. Since parsing can permute
+// arguments, the terminator may be at a different index in [State.Args].
+func (s *State) TermInd() int {
+	return s.termInd
+}
+
 // Reset recycles an existing [State], resetting it to parse options from args,
 // starting with the element at index 1.
 func (s *State) Reset(args []string) {
 	s.args = args
 	s.optInd = initOptInd
 	s.argInd = initArgInd
+	s.terminated = false
+	s.done = DoneNone
+	s.termInd = initTermInd
+	s.nonOptStart = initNonOpt
+	s.nonOptEnd = initNonOpt
+}
+
+// Clone returns a copy of [State] that can be parsed independently. The
+// argument slice is copied, so permuting arguments in the clone does not affect
+// the original.
+//
+// A clone can be used to try parsing speculatively, replacing the original with
+// the clone only if parsing succeeds.
+func (s *State) Clone() *State {
+	c := *s
+	c.args = slices.Clone(s.args)
+	return &c
+}
+
+// Peek returns the result of parsing the next option in [State], like
+// [State.GetOpt], but without advancing State or permuting its arguments.
+func (s *State) Peek(c Config) (Result, error) {
+	return s.Clone().GetOpt(c)
 }
 
 // GetOpt returns the result of parsing the next option in [State].
@@ -231,34 +628,46 @@ func (s *State) Reset(args []string) {
 // returned [Result] indicates either a valid option, or the properties of an
 // invalid option if err is non-nil.
 func (s *State) GetOpt(c Config) (res Result, err error) {
-	if s.optInd >= len(s.args) {
+	if s.terminated {
 		return res, ErrDone
 	}
 
+	switch c.Libc {
+	case LibcMusl:
+		return s.getOptMusl(c)
+	case LibcBSD:
+		return s.getOptBSD(c)
+	}
+
+	if s.optInd >= len(s.args) {
+		return s.finish(DoneEnd)
+	}
+
+	s.done = DoneNone
+	s.termInd = initTermInd
+
 	if s.args[s.optInd] == "--" {
-		s.optInd++
-		return res, ErrDone
+		return s.terminate(c)
 	}
 
 	// The algorithm for permuting arguments is from [musl-libc], and is used under the MIT License:
 	// Copyright © 2005-2020 Rich Felker, et al.
 	pStart := s.optInd
-	if s.args[s.optInd] == "" || s.args[s.optInd] == "-" || []rune(s.args[s.optInd])[0] != '-' {
+	if !isOpt(s.args[s.optInd], c) {
 		switch c.Mode {
 		case ModePOSIX:
-			return res, ErrDone
+			return s.finish(DoneParam)
 		case ModeInOrder:
 			s.optInd++
-			return Result{Char: '\x01', OptArg: s.args[s.optInd-1]}, nil
+			return Result{Kind: KindParam, Char: '\x01', OptArg: s.args[s.optInd-1], HasOptArg: true, Ind: s.optInd - 1}, nil
 		default:
 			for i := s.optInd; i < len(s.args); i++ {
-				arg := s.args[i]
-				if len(arg) > 1 && []rune(arg)[0] == '-' {
+				if isOpt(s.args[i], c) {
 					s.optInd = i
 					break
 				}
 				if i == len(s.args)-1 {
-					return res, ErrDone
+					return s.finish(DoneEnd)
 				}
 			}
 		}
@@ -266,10 +675,11 @@ func (s *State) GetOpt(c Config) (res Result, err error) {
 	pEnd := s.optInd
 
 	if s.args[s.optInd] == "--" {
-		s.optInd++
-		err = ErrDone
+		res, err = s.terminate(c)
 	} else {
+		ind, arg := s.optInd, s.args[s.optInd]
 		res, err = s.readOpt(c)
+		res, err = describe(c, arg, ind, res, err)
 	}
 
 	if pEnd > pStart {
@@ -282,59 +692,164 @@ func (s *State) GetOpt(c Config) (res Result, err error) {
 	return res, err
 }
 
+func (s *State) finish(reason DoneReason) (res Result, err error) {
+	if s.done == DoneNone {
+		s.done = reason
+	}
+	return res, ErrDone
+}
+
+func (s *State) terminate(c Config) (res Result, err error) {
+	s.done = DoneTerminator
+	s.termInd = s.optInd
+	s.optInd++
+	if c.YieldTerminator {
+		s.terminated = true
+		return Result{Kind: KindTerminator, Text: "--", Ind: s.termInd}, nil
+	}
+	return res, ErrDone
+}
+
+// describe sets the text and index of an option in res, which was parsed from
+// arg at index ind. If enabled in c, option errors are returned as an
+// [*OptError].
+func describe(c Config, arg string, ind int, res Result, err error) (Result, error) {
+	offset := res.offset
+	res.offset = 0
+
+	switch {
+	case res.Kind == KindShortOpt && err == ErrBundledOpt:
+		res.Prefix, _ = utf8.DecodeRuneInString(arg)
+		res.Text = arg
+	case res.Kind == KindShortOpt:
+		res.Prefix, _ = utf8.DecodeRuneInString(arg)
+		res.Text = string(res.Prefix) + string(res.Char)
+	case res.Kind == KindLongOpt:
+		res.Prefix, _ = utf8.DecodeRuneInString(arg)
+		res.Text, _, _ = strings.Cut(arg, "=")
+	case res.Kind == KindNumericOpt:
+		res.Prefix, _ = utf8.DecodeRuneInString(arg)
+		res.Text = string(res.Prefix) + res.OptArg
+	case res.Kind == KindNone:
+		return res, err
+	}
+	res.Ind = ind
+
+	if err != nil && c.DetailedErrors {
+		optErr := &OptError{Err: err, Result: res}
+		if res.Kind == KindShortOpt {
+			optErr.Offset = offset
+		}
+		switch err {
+		case ErrUnknownOpt:
+			optErr.Suggestion = suggest(arg, res, c)
+		case ErrMissingOptArg:
+			optErr.MinArgs = findNArgs(res, c).Min
+			optErr.Expected = expectedForm(res, c)
+		case ErrAttachedOptArg, ErrSeparateOptArg:
+			optErr.Expected = expectedForm(res, c)
+		case ErrBundledOpt:
+			if name := closestLongOpt(arg[len(string(res.Prefix)):], c); name != "" {
+				optErr.Suggestion = string(res.Prefix) + string(res.Prefix) + name
+			}
+		}
+		return res, optErr
+	}
+	return res, err
+}
+
 func (s *State) readOpt(c Config) (res Result, err error) {
 	arg := s.args[s.optInd]
+	_, prefixLen := utf8.DecodeRuneInString(arg)
 	checkLong := false
 	if s.argInd == 0 {
-		s.argInd++
+		s.argInd += prefixLen
 		checkLong = c.Func == FuncGetOptLongOnly
-		if arg[s.argInd] == '-' && c.Func != FuncGetOpt {
-			s.argInd++
+		if strings.HasPrefix(arg[s.argInd:], arg[:prefixLen]) && c.Func != FuncGetOpt {
+			s.argInd += prefixLen
+			checkLong = true
+		} else if c.Bundle == BundleLong && c.Func != FuncGetOpt && utf8.RuneCountInString(arg[s.argInd:]) > 1 {
 			checkLong = true
 		}
 	}
 
 	hasArg := NoArgument
+	var nArgs NArgs
+	strict := c.StrictRequiredArg
+	attach := AttachAny
 	name, inline, foundInline := strings.Cut(arg[s.argInd:], "=")
 
 	if checkLong && name != "" {
-		overrideOpt := s.argInd == 1 && c.Func == FuncGetOptLongOnly
-		opt, found := findLongOpt(name, overrideOpt, c)
+		overrideOpt := s.argInd == prefixLen && c.Func == FuncGetOptLongOnly
+		opt, negated, found := findLongOpt(name, overrideOpt, c)
 		if found {
 			s.optInd++
-			hasArg = opt.HasArg
+			hasArg, nArgs = opt.HasArg, opt.NArgs
+			strict = strict && !opt.AllowOptLikeArg
+			attach = cmp.Or(opt.Attach, c.LongAttach, AttachAny)
+			if negated {
+				hasArg, nArgs = NoArgument, NArgs{}
+			}
+			res.Kind = KindLongOpt
+			res.Char = opt.Char
 			res.Name = opt.Name
+			res.Negated = negated
 			if foundInline {
-				if opt.HasArg == NoArgument {
-					err = ErrIllegalOptArg
-				}
-				res.OptArg = inline
+				res.OptArg, res.HasOptArg = inline, true
 			}
 			s.argInd = 0
+		} else if s.argInd == 2*prefixLen {
+			// unlike with getopt_long_only, "--" options are never short options
+			s.optInd++
+			s.argInd = 0
+			return Result{Kind: KindLongOpt, Name: name}, ErrUnknownOpt
 		}
 	}
 
 	if res.Name == "" {
-		char, size := utf8.DecodeRuneInString(arg[s.argInd:])
+		char, size := decodeOpt(arg[s.argInd:], c)
+		res.Kind = KindShortOpt
 		res.Char = char
+		res.offset = s.argInd
 		opt, found := findOpt(char, c)
 		if found {
+			if grouped, at, ok := checkBundle(arg, s.argInd, size, prefixLen, opt, c); !ok {
+				s.optInd++
+				s.argInd = 0
+				return Result{Kind: KindShortOpt, Char: grouped, offset: at}, ErrBundledOpt
+			}
+			res.Char = opt.Char
 			s.argInd += size
-			hasArg = opt.HasArg
+			hasArg, nArgs = opt.HasArg, opt.NArgs
+			strict = strict && !opt.AllowOptLikeArg
+			attach = cmp.Or(opt.Attach, c.ShortAttach, AttachAny)
 
 			if arg[s.argInd:] == "" {
 				s.optInd++
 				s.argInd = 0
-			} else if hasArg != NoArgument {
-				res.OptArg = arg[s.argInd:]
+			} else if hasArg != NoArgument || nArgs.limit() > 0 || nArgs.verbatim() {
+				res.OptArg, res.HasOptArg = arg[s.argInd:], true
 				s.argInd = 0
 				s.optInd++
 			}
+		} else if isDigit(char) && c.NumericOpts && c.Libc == LibcGNU {
+			digits := strings.IndexFunc(arg[s.argInd:], func(r rune) bool { return !isDigit(r) })
+			if digits < 0 {
+				digits = len(arg) - s.argInd
+			}
+			res = Result{Kind: KindNumericOpt, OptArg: arg[s.argInd : s.argInd+digits], HasOptArg: true}
+			s.argInd += digits
+			if s.argInd == len(arg) {
+				s.optInd++
+				s.argInd = 0
+			}
+			return res, nil
 		} else {
 			s.argInd++
 			if checkLong {
 				s.optInd++
 				s.argInd = 0
+				res.Kind = KindLongOpt
 				res.Char = 0
 				res.Name = name
 			} else if arg[s.argInd:] == "" {
@@ -345,22 +860,99 @@ func (s *State) readOpt(c Config) (res Result, err error) {
 		}
 	}
 
-	if hasArg == RequiredArgument && res.OptArg == "" && s.optInd < len(s.args) {
-		res.OptArg = s.args[s.optInd]
-		s.optInd++
+	takesArgs := nArgs.limit() > 0 || nArgs.verbatim()
+	if res.HasOptArg && attach == AttachSeparate && (hasArg != NoArgument || takesArgs) {
+		return res, ErrAttachedOptArg
+	}
+
+	if takesArgs {
+		return s.readOptArgs(res, nArgs, c)
+	}
+
+	if hasArg == RequiredArgument && !res.HasOptArg && attach != AttachJoined && s.optInd < len(s.args) {
+		if !strict || !looksLikeOpt(s.args[s.optInd], c) {
+			res.OptArg, res.HasOptArg = s.args[s.optInd], true
+			s.optInd++
+		}
 	}
 
-	if res.OptArg != "" && hasArg == NoArgument {
+	if hasArg == OptionalArgument && !res.HasOptArg && c.SeparateOptArg && attach != AttachJoined && s.optInd < len(s.args) {
+		word := s.args[s.optInd]
+		if !looksLikeOpt(word, c) && (c.IsParam == nil || !c.IsParam(word)) {
+			res.OptArg, res.HasOptArg = word, true
+			s.optInd++
+		}
+	}
+
+	if res.HasOptArg && hasArg == NoArgument {
 		err = ErrIllegalOptArg
 	}
 
-	if res.OptArg == "" && hasArg == RequiredArgument {
+	if !res.HasOptArg && hasArg == RequiredArgument {
 		err = ErrMissingOptArg
+		if attach == AttachJoined && s.optInd < len(s.args) {
+			err = ErrSeparateOptArg
+		}
 	}
 
 	return res, err
 }
 
+// checkBundle reports whether opt, which was parsed at index i (with size bytes)
+// of arg, may be grouped as it is under the Bundle rule in c. If not, it
+// returns the character of the option grouped with the others, and its index in
+// arg.
+func checkBundle(arg string, i, size, prefixLen int, opt Opt, c Config) (grouped rune, at int, ok bool) {
+	takesArg := opt.HasArg != NoArgument || opt.NArgs.limit() > 0 || opt.NArgs.verbatim()
+	switch c.Bundle {
+	case BundleNoArg:
+		if i > prefixLen && takesArg {
+			return opt.Char, i, false
+		}
+	case BundleNone, BundleLong:
+		if i == prefixLen && !takesArg && i+size < len(arg) {
+			grouped, _ = utf8.DecodeRuneInString(arg[i+size:])
+			return grouped, i + size, false
+		}
+	}
+	return 0, 0, true
+}
+
+// readOptArgs parses the arguments of res, an option with rule n, following any
+// argument already parsed.
+func (s *State) readOptArgs(res Result, n NArgs, c Config) (Result, error) {
+	var more strings.Builder
+	count := 0
+	if res.HasOptArg {
+		count++
+	}
+	ended := n.Until == "" || n.Rest
+	for s.optInd < len(s.args) && (count < n.limit() || n.verbatim()) {
+		arg := s.args[s.optInd]
+		if n.Until != "" && arg == n.Until {
+			s.optInd++
+			ended = true
+			break
+		}
+		if !n.verbatim() && count >= n.Min && looksLikeOpt(arg, c) {
+			break
+		}
+		if res.HasOptArg {
+			more.WriteString(strconv.Itoa(len(arg)) + ":" + arg)
+		} else {
+			res.OptArg, res.HasOptArg = arg, true
+		}
+		count++
+		s.optInd++
+	}
+	res.moreOptArgs = more.String()
+
+	if count < n.Min || !ended {
+		return res, ErrMissingOptArg
+	}
+	return res, nil
+}
+
 func (s *State) permute(src, dest int) {
 	tmp := s.args[src]
 	for i := src; i > dest; i-- {
@@ -369,8 +961,81 @@ func (s *State) permute(src, dest int) {
 	s.args[dest] = tmp
 }
 
+// findNArgs returns the multiple argument rule of the option parsed in res.
+func findNArgs(res Result, c Config) NArgs {
+	if c.Libc != LibcGNU {
+		return NArgs{}
+	}
+	switch res.Kind {
+	case KindShortOpt:
+		opt, _ := findOpt(res.Char, c)
+		return opt.NArgs
+	case KindLongOpt:
+		if i := slices.IndexFunc(c.LongOpts, func(lo LongOpt) bool { return lo.Name == res.Name }); i >= 0 {
+			return c.LongOpts[i].NArgs
+		}
+	}
+	return NArgs{}
+}
+
+// expectedForm returns how the argument of the option parsed in res must be
+// given, if it is restricted by an Attach rule.
+func expectedForm(res Result, c Config) string {
+	if c.Libc != LibcGNU {
+		return ""
+	}
+	var attach Attach
+	switch res.Kind {
+	case KindShortOpt:
+		opt, _ := findOpt(res.Char, c)
+		attach = cmp.Or(opt.Attach, c.ShortAttach)
+	case KindLongOpt:
+		if i := slices.IndexFunc(c.LongOpts, func(lo LongOpt) bool { return lo.Name == res.Name }); i >= 0 {
+			attach = cmp.Or(c.LongOpts[i].Attach, c.LongAttach)
+		}
+	}
+	switch {
+	case attach == AttachJoined && res.Kind == KindLongOpt:
+		return res.Text + "=value"
+	case attach == AttachJoined:
+		return res.Text + "value"
+	case attach == AttachSeparate:
+		return res.Text + " value"
+	}
+	return ""
+}
+
+// isDigit reports whether r is an ASCII digit.
+func isDigit(r rune) bool {
+	return '0' <= r && r <= '9'
+}
+
+// looksLikeOpt reports whether word begins an option, or is the "--"
+// terminator.
+func looksLikeOpt(word string, c Config) bool {
+	return isOpt(word, c) || word == "--"
+}
+
+// isOpt reports whether arg begins with one of the prefix characters in c,
+// followed by an option.
+func isOpt(arg string, c Config) bool {
+	prefixes := "-"
+	if c.Prefixes != "" && c.Libc == LibcGNU {
+		prefixes = c.Prefixes
+	}
+	prefix, size := utf8.DecodeRuneInString(arg)
+	if size == 0 || size == len(arg) || !strings.ContainsRune(prefixes, prefix) {
+		return false
+	}
+	return prefix == '-' || arg[size:] != arg[:size]
+}
+
 func findOpt(char rune, c Config) (opt Opt, found bool) {
 	i := slices.IndexFunc(c.Opts, func(s Opt) bool { return char == s.Char })
+	if i < 0 && normalizes(c) {
+		norm := c.Normalize(string(char))
+		i = slices.IndexFunc(c.Opts, func(s Opt) bool { return norm == c.Normalize(string(s.Char)) })
+	}
 	if i >= 0 {
 		return c.Opts[i], true
 	} else {
@@ -378,32 +1043,150 @@ func findOpt(char rune, c Config) (opt Opt, found bool) {
 	}
 }
 
-func findLongOpt(name string, overrideOpt bool, c Config) (longOpt LongOpt, found bool) {
+func findLongOpt(name string, overrideOpt bool, c Config) (longOpt LongOpt, negated bool, found bool) {
 	if len([]rune(name)) == 1 && overrideOpt {
 		_, found := findOpt([]rune(name)[0], c)
 		if found {
-			return longOpt, false
+			return longOpt, false, false
 		}
 	}
 
 	matched := []LongOpt{}
+	negMatched := []LongOpt{}
+	stable := []LongOpt{}
+	abbrev := c.Abbrev != AbbrevExact && utf8.RuneCountInString(name) >= c.MinAbbrev
 
 	for _, lo := range c.LongOpts {
-		if lo.Name == name {
-			return lo, true
+		if equalName(lo.Name, name, c) {
+			return lo, false, true
+		}
+		if !hasNamePrefix(lo.Name, name, c) {
+			continue
 		}
-		if strings.HasPrefix(lo.Name, name) {
+		if lo.StableAbbrev != "" && hasNamePrefix(name, lo.StableAbbrev, c) {
+			stable = append(stable, lo)
+		}
+		if abbrev && !lo.NoAbbrev {
 			matched = append(matched, lo)
 		}
 	}
 
+	// A stable abbreviation takes precedence over other prefix matches.
+	if len(stable) > 0 {
+		return stable[0], false, true
+	}
+
+	// Negated forms are only matched once the "no-" prefix is typed in full,
+	// followed by at least part of a name, and are never chosen from an
+	// ambiguous abbreviation.
+	if base, ok := cutNamePrefix(name, negPrefix, c); ok && base != "" {
+		negAbbrev := c.Abbrev != AbbrevExact && utf8.RuneCountInString(base) >= c.MinAbbrev
+		for _, lo := range c.LongOpts {
+			if !lo.Negatable {
+				continue
+			}
+			if equalName(lo.Name, base, c) {
+				return lo, true, true
+			}
+			if negAbbrev && !lo.NoAbbrev && hasNamePrefix(lo.Name, base, c) {
+				negMatched = append(negMatched, lo)
+			}
+		}
+	}
+
+	if len(negMatched) > 0 {
+		if len(negMatched) == 1 && len(matched) == 0 {
+			return negMatched[0], true, true
+		}
+		return longOpt, false, false
+	}
+
 	if len(matched) == 1 {
-		return matched[0], true
+		return matched[0], false, true
 	}
 
 	if len(matched) > 0 && c.Func != FuncGetOptLongOnly {
-		return matched[0], true
+		return matched[0], false, true
 	}
 
-	return longOpt, false
+	return longOpt, false, false
+}
+
+// decodeOpt decodes the short option character at the start of s, returning it
+// and its size in bytes. If [Config.Normalize] combines the character with the
+// combining marks following it into a single character, it returns that
+// character and the size of the sequence.
+func decodeOpt(s string, c Config) (char rune, size int) {
+	char, size = utf8.DecodeRuneInString(s)
+	if !normalizes(c) {
+		return char, size
+	}
+	end := size
+	for end < len(s) {
+		r, n := utf8.DecodeRuneInString(s[end:])
+		if !unicode.Is(unicode.M, r) {
+			break
+		}
+		end += n
+	}
+	if end > size {
+		norm := c.Normalize(s[:end])
+		if r, n := utf8.DecodeRuneInString(norm); n > 0 && n == len(norm) {
+			return r, end
+		}
+	}
+	return char, size
+}
+
+// normalizes reports whether names and characters are normalized before they
+// are compared.
+func normalizes(c Config) bool {
+	return c.Normalize != nil && c.Libc == LibcGNU
+}
+
+// foldsCase reports whether long option names are compared under case folding.
+func foldsCase(c Config) bool {
+	return c.FoldCase && c.Libc == LibcGNU
+}
+
+// equalName reports whether the long option names a and b are equal, with
+// [Config.FoldCase] and [Config.Normalize] applied.
+func equalName(a, b string, c Config) bool {
+	if a == b {
+		return true
+	}
+	if normalizes(c) {
+		a, b = c.Normalize(a), c.Normalize(b)
+	}
+	if foldsCase(c) {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// hasNamePrefix reports whether the long option name begins with prefix, with
+// [Config.FoldCase] and [Config.Normalize] applied.
+func hasNamePrefix(name, prefix string, c Config) bool {
+	_, ok := cutNamePrefix(name, prefix, c)
+	return ok
+}
+
+// cutNamePrefix returns name without prefix, and whether name began with
+// prefix, with [Config.FoldCase] and [Config.Normalize] applied.
+func cutNamePrefix(name, prefix string, c Config) (after string, found bool) {
+	if normalizes(c) {
+		name, prefix = c.Normalize(name), c.Normalize(prefix)
+	}
+	if !foldsCase(c) {
+		return strings.CutPrefix(name, prefix)
+	}
+	after = name
+	for _, r := range prefix {
+		ar, size := utf8.DecodeRuneInString(after)
+		if size == 0 || !strings.EqualFold(string(ar), string(r)) {
+			return name, false
+		}
+		after = after[size:]
+	}
+	return after, true
 }
@@ -9,10 +9,26 @@ import (
 	"testing"
 )
 
-const fixturePath = "testdata/fixtures.json"
+const (
+	fixturePath     = "testdata/fixtures.json"
+	muslFixturePath = "testdata/fixtures_musl.json"
+	bsdFixturePath  = "testdata/fixtures_bsd.json"
+)
 
 func TestGetOpt_Fixtures(t *testing.T) {
-	fixtureFile, err := os.Open(fixturePath)
+	testFixtures(t, fixturePath, LibcGNU)
+}
+
+func TestGetOpt_MuslFixtures(t *testing.T) {
+	testFixtures(t, muslFixturePath, LibcMusl)
+}
+
+func TestGetOpt_BSDFixtures(t *testing.T) {
+	testFixtures(t, bsdFixturePath, LibcBSD)
+}
+
+func testFixtures(t *testing.T, path string, libc Libc) {
+	fixtureFile, err := os.Open(path)
 	if err != nil {
 		t.Fatalf("error opening fixtures file: %v", err)
 	}
@@ -32,6 +48,7 @@ func TestGetOpt_Fixtures(t *testing.T) {
 		if err := decoder.Decode(&f); err != nil {
 			t.Fatalf("error decoding fixture: %v", err)
 		}
+		f.Libc = libc
 		testName := fmt.Sprintf("%s %s %s)", f.Label, funcString(f.Func), modeString(f.Mode))
 		t.Run(testName, func(t *testing.T) {
 			assertFixture(t, f)
@@ -80,6 +97,7 @@ func assertFixture(t testing.TB, f fixture) {
 		LongOpts: f.LongOpts,
 		Mode:     f.Mode,
 		Func:     f.Func,
+		Libc:     f.Libc,
 	}
 
 	for iter, want := range f.WantResults {
@@ -106,6 +124,14 @@ func assertFixture(t testing.TB, f fixture) {
 		if res.OptArg != want.OptArg {
 			t.Errorf("iter %d, got OptArg %q, but wanted %q", iter, res.OptArg, want.OptArg)
 		}
+		if res.HasOptArg != want.HasOptArg {
+			t.Errorf("iter %d, got HasOptArg %t, but wanted %t", iter, res.HasOptArg, want.HasOptArg)
+		}
+		// OptInd mid-parse only matches glibc once permutation is complete
+		// (see README), so it is checked per iteration for other libcs.
+		if f.Libc != LibcGNU && s.optInd != want.OptInd {
+			t.Errorf("iter %d, got optInd %d, but wanted %d", iter, s.optInd, want.OptInd)
+		}
 	}
 
 	if s.optInd != f.WantOptInd {
@@ -118,16 +144,19 @@ func assertFixture(t testing.TB, f fixture) {
 }
 
 type fixtureResult struct {
-	Char   rune
-	Name   string
-	OptArg string
-	Err    error
+	Char      rune
+	Name      string
+	OptArg    string
+	HasOptArg bool
+	Err       error
+	OptInd    int
 }
 
 type fixture struct {
 	Label       string          `json:"label"`
 	Func        Func            `json:"func"`
 	Mode        Mode            `json:"mode"`
+	Libc        Libc            `json:"-"`
 	Args        []string        `json:"args"`
 	Opts        []Opt           `json:"opts"`
 	LongOpts    []LongOpt       `json:"lopts"`
@@ -209,10 +238,12 @@ func (f *fixture) UnmarshalJSON(data []byte) error {
 	f.WantResults = make([]fixtureResult, len(aux.WantResults))
 	for i, raw := range aux.WantResults {
 		var jsonResult struct {
-			Char   int    `json:"char"`
-			Name   string `json:"name"`
-			OptArg string `json:"optarg"`
-			Err    string `json:"err"`
+			Char      int    `json:"char"`
+			Name      string `json:"name"`
+			OptArg    string `json:"optarg"`
+			HasOptArg bool   `json:"has_optarg"`
+			Err       string `json:"err"`
+			OptInd    int    `json:"optind"`
 		}
 		if err := json.Unmarshal(raw, &jsonResult); err != nil {
 			return err
@@ -220,7 +251,9 @@ func (f *fixture) UnmarshalJSON(data []byte) error {
 		f.WantResults[i].Char = rune(jsonResult.Char)
 		f.WantResults[i].Name = jsonResult.Name
 		f.WantResults[i].OptArg = jsonResult.OptArg
+		f.WantResults[i].HasOptArg = jsonResult.HasOptArg
 		f.WantResults[i].Err = parseErr(jsonResult.Err)
+		f.WantResults[i].OptInd = jsonResult.OptInd
 	}
 
 	return nil
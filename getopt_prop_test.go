@@ -11,6 +11,7 @@ var (
 	genHasArg = rapid.SampledFrom([]getopt.HasArg{getopt.NoArgument, getopt.RequiredArgument, getopt.OptionalArgument})
 	funcGen   = rapid.SampledFrom([]getopt.Func{getopt.FuncGetOpt, getopt.FuncGetOptLong, getopt.FuncGetOptLongOnly})
 	modeGen   = rapid.SampledFrom([]getopt.Mode{getopt.ModeGNU, getopt.ModePOSIX, getopt.ModeInOrder})
+	libcGen   = rapid.SampledFrom([]getopt.Libc{getopt.LibcGNU, getopt.LibcMusl, getopt.LibcBSD})
 )
 
 var optGen = rapid.Custom(func(t *rapid.T) getopt.Opt {
@@ -27,6 +28,7 @@ var configGen = rapid.Custom(func(t *rapid.T) getopt.Config {
 		LongOpts: rapid.SliceOf(longOptGen).Draw(t, "long_opts"),
 		Func:     funcGen.Draw(t, "func"),
 		Mode:     modeGen.Draw(t, "mode"),
+		Libc:     libcGen.Draw(t, "libc"),
 	}
 })
 
@@ -43,11 +45,15 @@ func propTarget(t *rapid.T) {
 		}
 
 		if err != nil {
-			if err == getopt.ErrMissingOptArg && res.OptArg != "" {
+			if err == getopt.ErrMissingOptArg && (res.OptArg != "" || res.HasOptArg) {
 				t.Fatalf("result has OptArg %q, but err claims it is missing", res.OptArg)
 			}
 		}
 
+		if res.OptArg != "" && !res.HasOptArg {
+			t.Fatalf("result has OptArg %q, but HasOptArg is false", res.OptArg)
+		}
+
 		if res.Char != 0 {
 			if res.Name != "" {
 				t.Fatalf("result has both Char %q and Name %q", res.Char, res.Name)
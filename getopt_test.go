@@ -32,9 +32,9 @@ func TestParse(t *testing.T) {
 		c := Config{Opts: OptStr(`abc`)}
 		got, err := s.Parse(c)
 		want := []Result{
-			{Char: 'a'},
-			{Char: 'b'},
-			{Char: 'c'},
+			{Kind: KindShortOpt, Char: 'a', Prefix: '-', Text: "-a", Ind: 1},
+			{Kind: KindShortOpt, Char: 'b', Prefix: '-', Text: "-b", Ind: 2},
+			{Kind: KindShortOpt, Char: 'c', Prefix: '-', Text: "-c", Ind: 2},
 		}
 
 		if err != nil {
@@ -56,7 +56,7 @@ func TestParse(t *testing.T) {
 		c := Config{Opts: OptStr(`abc`)}
 		got, err := s.Parse(c)
 		want := []Result{
-			{Char: 'a'},
+			{Kind: KindShortOpt, Char: 'a', Prefix: '-', Text: "-a", Ind: 1},
 		}
 
 		if err != ErrUnknownOpt {
@@ -74,6 +74,92 @@ func TestParse(t *testing.T) {
 	})
 }
 
+func TestParseAll(t *testing.T) {
+	t.Run("without errors", func(t *testing.T) {
+		s := testState(`prgm -a p1 -b`)
+		c := Config{Opts: OptStr(`ab`)}
+		got, err := s.ParseAll(c)
+		if err != nil {
+			t.Fatalf("got error %v, but didn't expect one", err)
+		}
+		if len(got) != 2 {
+			t.Errorf("got len %d, but wanted %d", len(got), 2)
+		}
+	})
+
+	t.Run("with errors", func(t *testing.T) {
+		s := testState(`prgm -b -d --longa=a1 p1 -a -e -b`)
+		c := Config{
+			Opts:     OptStr(`ab:`),
+			LongOpts: []LongOpt{{Name: "longa", HasArg: NoArgument}},
+			Func:     FuncGetOptLong,
+		}
+		got, err := s.ParseAll(c)
+		want := []Result{
+			{Kind: KindShortOpt, Char: 'b', OptArg: "-d", HasOptArg: true, Prefix: '-', Text: "-b", Ind: 1},
+			{Kind: KindShortOpt, Char: 'a', Prefix: '-', Text: "-a", Ind: 5},
+		}
+		wantErrs := []struct {
+			err  error
+			text string
+			ind  int
+		}{
+			{err: ErrIllegalOptArg, text: "--longa", ind: 3},
+			{err: ErrUnknownOpt, text: "-e", ind: 6},
+			{err: ErrMissingOptArg, text: "-b", ind: 7},
+		}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("got %+v, but wanted %+v", got, want)
+		}
+		joined, ok := err.(interface{ Unwrap() []error })
+		if !ok {
+			t.Fatalf("got error %v, but wanted joined errors", err)
+		}
+		errs := joined.Unwrap()
+		if len(errs) != len(wantErrs) {
+			t.Fatalf("got %d errors, but wanted %d", len(errs), len(wantErrs))
+		}
+		for i, want := range wantErrs {
+			var optErr *OptError
+			if !errors.As(errs[i], &optErr) {
+				t.Fatalf("got error %v, but wanted an *OptError", errs[i])
+			}
+			if optErr.Err != want.err {
+				t.Errorf("got error %v, but wanted %v", optErr.Err, want.err)
+			}
+			if optErr.Result.Text != want.text || optErr.Result.Ind != want.ind {
+				t.Errorf("got %q at %d, but wanted %q at %d", optErr.Result.Text, optErr.Result.Ind, want.text, want.ind)
+			}
+		}
+
+		wantParams := argsStr(`p1`)
+
+		if !slices.Equal(s.Params(), wantParams) {
+			t.Errorf("got %+v, but wanted %+v", s.Params(), wantParams)
+		}
+	})
+
+	t.Run("with detailed errors", func(t *testing.T) {
+		s := testState(`prgm --verbsoe --quiet`)
+		c := Config{
+			LongOpts:       []LongOpt{{Name: "verbose", HasArg: NoArgument}},
+			Func:           FuncGetOptLong,
+			DetailedErrors: true,
+		}
+		_, err := s.ParseAll(c)
+		want := "getopt: unrecognized option: --verbsoe (did you mean --verbose?)\n" +
+			"getopt: unrecognized option: --quiet"
+
+		if !errors.Is(err, ErrUnknownOpt) {
+			t.Fatalf("got error %v, but wanted %v", err, ErrUnknownOpt)
+		}
+		if err.Error() != want {
+			t.Errorf("got %q, but wanted %q", err.Error(), want)
+		}
+	})
+}
+
 func TestAll(t *testing.T) {
 	s := testState(`prgm -a -dc`)
 	c := Config{Opts: OptStr(`abc`)}
@@ -108,6 +194,595 @@ func TestAll(t *testing.T) {
 	}
 }
 
+func TestKind(t *testing.T) {
+	s := testState(`prgm -a --longa p1 -d --longd -- p2`)
+	c := Config{
+		Opts:     OptStr(`a`),
+		LongOpts: LongOptStr(`longa`),
+		Func:     FuncGetOptLong,
+		Mode:     ModeInOrder,
+	}
+	want := []Kind{KindShortOpt, KindLongOpt, KindParam, KindShortOpt, KindLongOpt}
+
+	var got []Kind
+	for res := range s.All(c) {
+		got = append(got, res.Kind)
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, but wanted %v", got, want)
+	}
+}
+
+func TestLocate(t *testing.T) {
+	args := argsStr(`prgm p1 -ab --longa --lon=a1 p2 -c a2 -longa p3`)
+	want := []struct {
+		text string
+		ind  int
+	}{
+		{"-a", 2}, {"-b", 2}, {"--longa", 3}, {"--lon", 4}, {"-c", 6}, {"-longa", 8},
+	}
+
+	for _, libc := range []Libc{LibcGNU, LibcMusl, LibcBSD} {
+		s := NewState(slices.Clone(args))
+		c := Config{
+			Opts:     OptStr(`abc:`),
+			LongOpts: LongOptStr(`longa,longb:`),
+			Func:     FuncGetOptLongOnly,
+			Libc:     libc,
+		}
+
+		i := 0
+		for res, err := range s.All(c) {
+			if i >= len(want) {
+				t.Fatalf("libc %d: got extra result %+v", libc, res)
+			}
+			if err != nil && !errors.Is(err, ErrUnknownOpt) {
+				t.Errorf("libc %d: got error %q, but didn't expect one", libc, err)
+			}
+			if res.Text != want[i].text || res.Ind != want[i].ind {
+				t.Errorf("libc %d: got Text %q and Ind %d, but wanted %q and %d", libc, res.Text, res.Ind, want[i].text, want[i].ind)
+			}
+			i++
+		}
+	}
+}
+
+func TestYieldTerminator(t *testing.T) {
+	t.Run("it yields the terminator", func(t *testing.T) {
+		s := testState(`prgm -a p1 -- -b`)
+		c := Config{Opts: OptStr(`ab`), YieldTerminator: true}
+		got, err := s.Parse(c)
+		want := []Result{
+			{Kind: KindShortOpt, Char: 'a', Prefix: '-', Text: "-a", Ind: 1},
+			{Kind: KindTerminator, Text: "--", Ind: 3},
+		}
+
+		if err != nil {
+			t.Fatalf("got error %q, but didn't expect one", err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %+v, but wanted %+v", got, want)
+		}
+
+		wantParams := argsStr(`p1 -b`)
+		if !slices.Equal(s.Params(), wantParams) {
+			t.Errorf("got %+q, but wanted %+q", s.Params(), wantParams)
+		}
+	})
+
+	t.Run("it is done after the terminator", func(t *testing.T) {
+		s := testState(`prgm -- -a`)
+		c := Config{Opts: OptStr(`a`), YieldTerminator: true}
+		wants := []assertion{
+			{args: argsStr(`prgm -- -a`), optInd: 2},
+			{err: ErrDone, args: argsStr(`prgm -- -a`), optInd: 2},
+			{err: ErrDone, args: argsStr(`prgm -- -a`), optInd: 2},
+		}
+
+		assertSeq(t, s, c, wants)
+	})
+
+	t.Run("it parses again when reset", func(t *testing.T) {
+		s := testState(`prgm --`)
+		c := Config{YieldTerminator: true}
+
+		s.Parse(c)
+		s.Reset(argsStr(`prgm -a`))
+
+		res, err := s.GetOpt(Config{Opts: OptStr(`a`)})
+		if err != nil {
+			t.Fatalf("got error %q, but didn't expect one", err)
+		}
+		if res.Char != 'a' {
+			t.Errorf("got Char %q, but wanted %q", res.Char, 'a')
+		}
+	})
+}
+
+func TestPrefixes(t *testing.T) {
+	t.Run("it parses options with each prefix", func(t *testing.T) {
+		s := testState(`prgm -a +a p1 +ab ++verbose=1 + ++ -- +b`)
+		c := Config{
+			Opts:     OptStr(`ab`),
+			LongOpts: LongOptStr(`verbose:`),
+			Func:     FuncGetOptLong,
+			Prefixes: "-+",
+		}
+		got, err := s.Parse(c)
+		want := []Result{
+			{Kind: KindShortOpt, Char: 'a', Prefix: '-', Text: "-a", Ind: 1},
+			{Kind: KindShortOpt, Char: 'a', Prefix: '+', Text: "+a", Ind: 2},
+			{Kind: KindShortOpt, Char: 'a', Prefix: '+', Text: "+a", Ind: 4},
+			{Kind: KindShortOpt, Char: 'b', Prefix: '+', Text: "+b", Ind: 4},
+			{Kind: KindLongOpt, Name: "verbose", OptArg: "1", HasOptArg: true, Prefix: '+', Text: "++verbose", Ind: 5},
+		}
+
+		if err != nil {
+			t.Fatalf("got error %q, but didn't expect one", err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %+v, but wanted %+v", got, want)
+		}
+
+		wantParams := argsStr(`p1 + ++ +b`)
+		if !slices.Equal(s.Params(), wantParams) {
+			t.Errorf("got %+q, but wanted %+q", s.Params(), wantParams)
+		}
+	})
+
+	t.Run("it parses DOS-style switches", func(t *testing.T) {
+		s := testState(`prgm /v -v /verbose /`)
+		c := Config{
+			Opts:     OptStr(`v`),
+			LongOpts: LongOptStr(`verbose`),
+			Func:     FuncGetOptLongOnly,
+			Prefixes: "/",
+		}
+		got, err := s.Parse(c)
+		want := []Result{
+			{Kind: KindShortOpt, Char: 'v', Prefix: '/', Text: "/v", Ind: 1},
+			{Kind: KindLongOpt, Name: "verbose", Prefix: '/', Text: "/verbose", Ind: 3},
+		}
+
+		if err != nil {
+			t.Fatalf("got error %q, but didn't expect one", err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %+v, but wanted %+v", got, want)
+		}
+
+		wantParams := argsStr(`-v /`)
+		if !slices.Equal(s.Params(), wantParams) {
+			t.Errorf("got %+q, but wanted %+q", s.Params(), wantParams)
+		}
+	})
+}
+
+func TestNumericOpts(t *testing.T) {
+	s := testState(`prgm -20 p1 -v5 -n -5 -1v -0 +3`)
+	c := Config{
+		Opts:        OptStr(`vn:0`),
+		NumericOpts: true,
+		Prefixes:    "-+",
+	}
+	got, err := s.Parse(c)
+	want := []Result{
+		{Kind: KindNumericOpt, OptArg: "20", HasOptArg: true, Prefix: '-', Text: "-20", Ind: 1},
+		{Kind: KindShortOpt, Char: 'v', Prefix: '-', Text: "-v", Ind: 3},
+		{Kind: KindNumericOpt, OptArg: "5", HasOptArg: true, Prefix: '-', Text: "-5", Ind: 3},
+		{Kind: KindShortOpt, Char: 'n', OptArg: "-5", HasOptArg: true, Prefix: '-', Text: "-n", Ind: 4},
+		{Kind: KindNumericOpt, OptArg: "1", HasOptArg: true, Prefix: '-', Text: "-1", Ind: 6},
+		{Kind: KindShortOpt, Char: 'v', Prefix: '-', Text: "-v", Ind: 6},
+		{Kind: KindShortOpt, Char: '0', Prefix: '-', Text: "-0", Ind: 7},
+		{Kind: KindNumericOpt, OptArg: "3", HasOptArg: true, Prefix: '+', Text: "+3", Ind: 8},
+	}
+
+	if err != nil {
+		t.Fatalf("got error %q, but didn't expect one", err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %+v, but wanted %+v", got, want)
+	}
+
+	wantParams := argsStr(`p1`)
+	if !slices.Equal(s.Params(), wantParams) {
+		t.Errorf("got %+q, but wanted %+q", s.Params(), wantParams)
+	}
+}
+
+func TestNArgs(t *testing.T) {
+	testConfig := func() Config {
+		return Config{
+			Opts: []Opt{{Char: 'r', NArgs: NArgs{Min: 2}}, {Char: 'x'}},
+			LongOpts: []LongOpt{
+				{Name: "point", NArgs: NArgs{Min: 2}},
+				{Name: "tags", NArgs: NArgs{Min: 1, Max: 3}},
+			},
+			Func:           FuncGetOptLong,
+			DetailedErrors: true,
+		}
+	}
+
+	t.Run("it parses multiple arguments", func(t *testing.T) {
+		s := testState(`prgm --point 1 2 p1 -r 0 -5 -x --tags a b -x --tags=c d e f`)
+		got, err := s.Parse(testConfig())
+		want := []Result{
+			{Kind: KindLongOpt, Name: "point", OptArg: "1", HasOptArg: true, Prefix: '-', Text: "--point", Ind: 1, moreOptArgs: "1:2"},
+			{Kind: KindShortOpt, Char: 'r', OptArg: "0", HasOptArg: true, Prefix: '-', Text: "-r", Ind: 5, moreOptArgs: "2:-5"},
+			{Kind: KindShortOpt, Char: 'x', Prefix: '-', Text: "-x", Ind: 8},
+			{Kind: KindLongOpt, Name: "tags", OptArg: "a", HasOptArg: true, Prefix: '-', Text: "--tags", Ind: 9, moreOptArgs: "1:b"},
+			{Kind: KindShortOpt, Char: 'x', Prefix: '-', Text: "-x", Ind: 12},
+			{Kind: KindLongOpt, Name: "tags", OptArg: "c", HasOptArg: true, Prefix: '-', Text: "--tags", Ind: 13, moreOptArgs: "1:d1:e"},
+		}
+
+		if err != nil {
+			t.Fatalf("got error %q, but didn't expect one", err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %+v, but wanted %+v", got, want)
+		}
+
+		wantParams := argsStr(`p1 f`)
+		if !slices.Equal(s.Params(), wantParams) {
+			t.Errorf("got %+q, but wanted %+q", s.Params(), wantParams)
+		}
+	})
+
+	t.Run("it returns all arguments", func(t *testing.T) {
+		s := NewState([]string{"prgm", "--tags", "a", "", "c"})
+		res, err := s.GetOpt(testConfig())
+		if err != nil {
+			t.Fatalf("got error %q, but didn't expect one", err)
+		}
+
+		want := []string{"a", "", "c"}
+		if !slices.Equal(res.OptArgs(), want) {
+			t.Errorf("got %+q, but wanted %+q", res.OptArgs(), want)
+		}
+	})
+
+	t.Run("it returns arguments containing any bytes", func(t *testing.T) {
+		s := NewState([]string{"prgm", "--tags", "a\x00b", "c\x00", "1:d"})
+		res, err := s.GetOpt(testConfig())
+		if err != nil {
+			t.Fatalf("got error %q, but didn't expect one", err)
+		}
+
+		want := []string{"a\x00b", "c\x00", "1:d"}
+		if !slices.Equal(res.OptArgs(), want) {
+			t.Errorf("got %+q, but wanted %+q", res.OptArgs(), want)
+		}
+	})
+
+	t.Run("it parses the rest of the arguments", func(t *testing.T) {
+		s := testState(`prgm -x p1 -e prog -x -- a`)
+		c := Config{Opts: []Opt{{Char: 'e', NArgs: NArgs{Min: 1, Rest: true}}, {Char: 'x'}}}
+		got, err := s.Parse(c)
+		want := []Result{
+			{Kind: KindShortOpt, Char: 'x', Prefix: '-', Text: "-x", Ind: 1},
+			{Kind: KindShortOpt, Char: 'e', OptArg: "prog", HasOptArg: true, Prefix: '-', Text: "-e", Ind: 3, moreOptArgs: "2:-x2:--1:a"},
+		}
+
+		if err != nil {
+			t.Fatalf("got error %q, but didn't expect one", err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %+v, but wanted %+v", got, want)
+		}
+
+		wantParams := argsStr(`p1`)
+		if !slices.Equal(s.Params(), wantParams) {
+			t.Errorf("got %+q, but wanted %+q", s.Params(), wantParams)
+		}
+	})
+
+	t.Run("it parses arguments up to a terminator", func(t *testing.T) {
+		s := testState(`prgm p1 -exec rm -f {} ; -name x p2`)
+		c := Config{
+			LongOpts: []LongOpt{
+				{Name: "exec", NArgs: NArgs{Min: 1, Until: ";"}},
+				{Name: "name", HasArg: RequiredArgument},
+			},
+			Func: FuncGetOptLongOnly,
+		}
+		got, err := s.Parse(c)
+		want := []Result{
+			{Kind: KindLongOpt, Name: "exec", OptArg: "rm", HasOptArg: true, Prefix: '-', Text: "-exec", Ind: 2, moreOptArgs: "2:-f2:{}"},
+			{Kind: KindLongOpt, Name: "name", OptArg: "x", HasOptArg: true, Prefix: '-', Text: "-name", Ind: 7},
+		}
+
+		if err != nil {
+			t.Fatalf("got error %q, but didn't expect one", err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %+v, but wanted %+v", got, want)
+		}
+
+		wantParams := argsStr(`p1 p2`)
+		if !slices.Equal(s.Params(), wantParams) {
+			t.Errorf("got %+q, but wanted %+q", s.Params(), wantParams)
+		}
+	})
+
+	t.Run("it reports a missing terminator", func(t *testing.T) {
+		s := testState(`prgm -exec rm {}`)
+		c := Config{
+			LongOpts: []LongOpt{{Name: "exec", NArgs: NArgs{Until: ";"}}},
+			Func:     FuncGetOptLongOnly,
+		}
+		_, err := s.Parse(c)
+		if !errors.Is(err, ErrMissingOptArg) {
+			t.Errorf("got error %v, but wanted %v", err, ErrMissingOptArg)
+		}
+	})
+
+	t.Run("it reports too few arguments", func(t *testing.T) {
+		s := testState(`prgm -x --point 1`)
+		_, err := s.Parse(testConfig())
+		if !errors.Is(err, ErrMissingOptArg) {
+			t.Fatalf("got error %v, but wanted %v", err, ErrMissingOptArg)
+		}
+
+		want := "getopt: option requires an argument: --point (requires 2 arguments, got 1)"
+		if err.Error() != want {
+			t.Errorf("got message %q, but wanted %q", err.Error(), want)
+		}
+	})
+}
+
+func TestStrictRequiredArg(t *testing.T) {
+	s := testState(`prgm -o -v -n -5 -o - -ofile --out -v -o --`)
+	c := Config{
+		Opts:              []Opt{{Char: 'o', HasArg: RequiredArgument}, {Char: 'v'}, {Char: 'n', HasArg: RequiredArgument, AllowOptLikeArg: true}},
+		LongOpts:          LongOptStr(`out:`),
+		Func:              FuncGetOptLong,
+		StrictRequiredArg: true,
+	}
+	args := argsStr(`prgm -o -v -n -5 -o - -ofile --out -v -o --`)
+	wants := []assertion{
+		{char: 'o', err: ErrMissingOptArg, args: args, optInd: 2},
+		{char: 'v', args: args, optInd: 3},
+		{char: 'n', optArg: "-5", args: args, optInd: 5},
+		{char: 'o', optArg: "-", args: args, optInd: 7},
+		{char: 'o', optArg: "file", args: args, optInd: 8},
+		{name: "out", err: ErrMissingOptArg, args: args, optInd: 9},
+		{char: 'v', args: args, optInd: 10},
+		{char: 'o', err: ErrMissingOptArg, args: args, optInd: 11},
+		{err: ErrDone, args: args, optInd: 12},
+	}
+
+	assertSeq(t, s, c, wants)
+}
+
+func TestBundle(t *testing.T) {
+	tests := []struct {
+		label  string
+		bundle Bundle
+		args   string
+		wants  []assertion
+	}{
+		{
+			label:  "it groups any options",
+			bundle: BundleAny,
+			args:   `prgm -ab -ao x -aox`,
+			wants: []assertion{
+				{char: 'a', optInd: 1}, {char: 'b', optInd: 2},
+				{char: 'a', optInd: 2}, {char: 'o', optArg: "x", optInd: 4},
+				{char: 'a', optInd: 4}, {char: 'o', optArg: "x", optInd: 5},
+				{err: ErrDone, optInd: 5},
+			},
+		},
+		{
+			label:  "it groups only options without arguments",
+			bundle: BundleNoArg,
+			args:   `prgm -ab -ao -ox`,
+			wants: []assertion{
+				{char: 'a', optInd: 1}, {char: 'b', optInd: 2},
+				{char: 'a', optInd: 2}, {char: 'o', err: ErrBundledOpt, optInd: 3},
+				{char: 'o', optArg: "x", optInd: 4},
+				{err: ErrDone, optInd: 4},
+			},
+		},
+		{
+			label:  "it does not group options",
+			bundle: BundleNone,
+			args:   `prgm -a -ab -ox -verbose`,
+			wants: []assertion{
+				{char: 'a', optInd: 2},
+				{char: 'b', err: ErrBundledOpt, optInd: 3},
+				{char: 'o', optArg: "x", optInd: 4},
+				{char: 'e', err: ErrBundledOpt, optInd: 5},
+				{err: ErrDone, optInd: 5},
+			},
+		},
+		{
+			label:  "it parses groups as long options",
+			bundle: BundleLong,
+			args:   `prgm -a -verb -ab -ox -xyz`,
+			wants: []assertion{
+				{char: 'a', optInd: 2},
+				{name: "verbose", optInd: 3},
+				{char: 'b', err: ErrBundledOpt, optInd: 4},
+				{char: 'o', optArg: "x", optInd: 5},
+				{name: "xyz", err: ErrUnknownOpt, optInd: 6},
+				{err: ErrDone, optInd: 6},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.label, func(t *testing.T) {
+			s := testState(test.args)
+			c := Config{
+				Opts:     OptStr(`abo:v`),
+				LongOpts: LongOptStr(`verbose`),
+				Func:     FuncGetOptLong,
+				Bundle:   test.bundle,
+			}
+			for _, want := range test.wants {
+				want.args = argsStr(test.args)
+				assertGetOpt(t, s, c, want)
+			}
+		})
+	}
+
+	t.Run("it suggests a long option", func(t *testing.T) {
+		s := testState(`prgm -verbose`)
+		c := Config{
+			Opts:           OptStr(`v`),
+			LongOpts:       LongOptStr(`verbose`),
+			Func:           FuncGetOptLong,
+			Bundle:         BundleNone,
+			DetailedErrors: true,
+		}
+		_, err := s.Parse(c)
+
+		want := "getopt: option may not be grouped: -verbose (did you mean --verbose?)"
+		if err == nil || err.Error() != want {
+			t.Errorf("got error %v, but wanted %q", err, want)
+		}
+	})
+}
+
+func TestAttach(t *testing.T) {
+	testConfig := func() Config {
+		return Config{
+			Opts:           []Opt{{Char: 'o', HasArg: RequiredArgument}, {Char: 'j', HasArg: RequiredArgument, Attach: AttachJoined}},
+			LongOpts:       []LongOpt{{Name: "out", HasArg: RequiredArgument}, {Name: "name", HasArg: RequiredArgument, Attach: AttachAny}},
+			Func:           FuncGetOptLong,
+			ShortAttach:    AttachSeparate,
+			LongAttach:     AttachJoined,
+			DetailedErrors: true,
+		}
+	}
+
+	t.Run("it parses arguments by attachment rules", func(t *testing.T) {
+		s := testState(`prgm -o a -oa -jb --out=x --name z --name=w -j --out y`)
+		args := argsStr(`prgm -o a -oa -jb --out=x --name z --name=w -j --out y`)
+		wants := []assertion{
+			{char: 'o', optArg: "a", args: args, optInd: 3},
+			{char: 'o', optArg: "a", err: ErrAttachedOptArg, args: args, optInd: 4},
+			{char: 'j', optArg: "b", args: args, optInd: 5},
+			{name: "out", optArg: "x", args: args, optInd: 6},
+			{name: "name", optArg: "z", args: args, optInd: 8},
+			{name: "name", optArg: "w", args: args, optInd: 9},
+			{char: 'j', err: ErrSeparateOptArg, args: args, optInd: 10},
+			{name: "out", err: ErrSeparateOptArg, args: args, optInd: 11},
+			{err: ErrDone, args: args, optInd: 11},
+		}
+
+		assertSeq(t, s, testConfig(), wants)
+	})
+
+	t.Run("it returns separate arguments without detailed errors", func(t *testing.T) {
+		c := testConfig()
+		c.DetailedErrors = false
+		s := testState(`prgm --out y -j`)
+		args := argsStr(`prgm --out y -j`)
+		wants := []assertion{
+			{name: "out", err: ErrSeparateOptArg, args: args, optInd: 2},
+			{char: 'j', err: ErrMissingOptArg, args: argsStr(`prgm --out -j y`), optInd: 3},
+			{err: ErrDone, args: argsStr(`prgm --out -j y`), optInd: 3},
+		}
+
+		assertSeq(t, s, c, wants)
+	})
+
+	t.Run("it explains the expected form", func(t *testing.T) {
+		tests := []struct {
+			args string
+			want string
+		}{
+			{`prgm -oa`, "getopt: option disallows attached arguments: -o (expected -o value)"},
+			{`prgm -j b`, "getopt: option requires an attached argument: -j (expected -jvalue)"},
+			{`prgm --out y`, "getopt: option requires an attached argument: --out (expected --out=value)"},
+			{`prgm --out`, "getopt: option requires an argument: --out (expected --out=value)"},
+		}
+
+		for _, test := range tests {
+			s := testState(test.args)
+			_, err := s.Parse(testConfig())
+			if err == nil || err.Error() != test.want {
+				t.Errorf("got error %v, but wanted %q", err, test.want)
+			}
+		}
+	})
+}
+
+func TestSeparateOptArg(t *testing.T) {
+	s := testState(`prgm --color always -c auto --color -v --color=never --color p1.txt -c`)
+	c := Config{
+		Opts:           OptStr(`c::v`),
+		LongOpts:       LongOptStr(`color::`),
+		Func:           FuncGetOptLong,
+		SeparateOptArg: true,
+		IsParam:        func(word string) bool { return strings.HasSuffix(word, ".txt") },
+	}
+	got, err := s.Parse(c)
+	want := []Result{
+		{Kind: KindLongOpt, Name: "color", OptArg: "always", HasOptArg: true, Prefix: '-', Text: "--color", Ind: 1},
+		{Kind: KindShortOpt, Char: 'c', OptArg: "auto", HasOptArg: true, Prefix: '-', Text: "-c", Ind: 3},
+		{Kind: KindLongOpt, Name: "color", Prefix: '-', Text: "--color", Ind: 5},
+		{Kind: KindShortOpt, Char: 'v', Prefix: '-', Text: "-v", Ind: 6},
+		{Kind: KindLongOpt, Name: "color", OptArg: "never", HasOptArg: true, Prefix: '-', Text: "--color", Ind: 7},
+		{Kind: KindLongOpt, Name: "color", Prefix: '-', Text: "--color", Ind: 8},
+		{Kind: KindShortOpt, Char: 'c', Prefix: '-', Text: "-c", Ind: 10},
+	}
+
+	if err != nil {
+		t.Fatalf("got error %q, but didn't expect one", err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %+v, but wanted %+v", got, want)
+	}
+
+	wantParams := argsStr(`p1.txt`)
+	if !slices.Equal(s.Params(), wantParams) {
+		t.Errorf("got %+q, but wanted %+q", s.Params(), wantParams)
+	}
+}
+
+func TestDone(t *testing.T) {
+	tests := []struct {
+		label       string
+		args        string
+		mode        Mode
+		yield       bool
+		wantDone    DoneReason
+		wantTermInd int
+	}{
+		{label: "end", args: `prgm -a p1`, mode: ModeGNU, wantDone: DoneEnd, wantTermInd: -1},
+		{label: "end without args", args: `prgm`, mode: ModeGNU, wantDone: DoneEnd, wantTermInd: -1},
+		{label: "end in inorder mode", args: `prgm -a p1`, mode: ModeInOrder, wantDone: DoneEnd, wantTermInd: -1},
+		{label: "param in posix mode", args: `prgm -a p1 -- p2`, mode: ModePOSIX, wantDone: DoneParam, wantTermInd: -1},
+		{label: "terminator", args: `prgm -a -- p1`, mode: ModeGNU, wantDone: DoneTerminator, wantTermInd: 2},
+		{label: "permuted terminator", args: `prgm p1 -a p2 -- p3`, mode: ModeGNU, wantDone: DoneTerminator, wantTermInd: 4},
+		{label: "yielded terminator", args: `prgm p1 -a p2 -- p3`, mode: ModeGNU, yield: true, wantDone: DoneTerminator, wantTermInd: 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.label, func(t *testing.T) {
+			s := testState(test.args)
+			c := Config{Opts: OptStr(`a`), Mode: test.mode, YieldTerminator: test.yield}
+
+			if s.Done() != DoneNone {
+				t.Errorf("got Done %v before parsing, but wanted %v", s.Done(), DoneNone)
+			}
+
+			for range s.All(c) {
+			}
+
+			if s.Done() != test.wantDone {
+				t.Errorf("got Done %v, but wanted %v", s.Done(), test.wantDone)
+			}
+			if s.TermInd() != test.wantTermInd {
+				t.Errorf("got TermInd %d, but wanted %d", s.TermInd(), test.wantTermInd)
+			}
+		})
+	}
+}
+
 func TestNew(t *testing.T) {
 	got := NewState(argsStr(`prgm -a -b`))
 	want := State{optInd: 1, args: []string{"prgm", "-a", "-b"}}
@@ -187,10 +862,137 @@ func TestReset(t *testing.T) {
 	})
 }
 
+func TestReset_LibcBSD(t *testing.T) {
+	s := NewState(argsStr(`prgm p1 -a p2`))
+	c := Config{Opts: OptStr(`ab`), Func: FuncGetOptLong, Libc: LibcBSD}
+
+	assertGetOpt(t, s, c, assertion{
+		char:   'a',
+		args:   argsStr(`prgm p1 -a p2`),
+		optInd: 3,
+	})
+
+	s.Reset(argsStr(`prgm -b p3`))
+
+	assertSeq(t, s, c, []assertion{
+		{char: 'b', args: argsStr(`prgm -b p3`), optInd: 2},
+		{err: ErrDone, args: argsStr(`prgm -b p3`), optInd: 2},
+	})
+}
+
+func TestSolarisOptStr(t *testing.T) {
+	tests := []struct {
+		optStr       string
+		wantOpts     []Opt
+		wantLongOpts []LongOpt
+	}{
+		{``, nil, nil},
+		{`ab:`, OptStr(`ab:`), nil},
+		{
+			`a(all)b:(bytes)c::(color)`,
+			OptStr(`ab:c::`),
+			[]LongOpt{
+				{Name: "all", HasArg: NoArgument, Char: 'a'},
+				{Name: "bytes", HasArg: RequiredArgument, Char: 'b'},
+				{Name: "color", HasArg: OptionalArgument, Char: 'c'},
+			},
+		},
+		{
+			`f:(file)(input)v`,
+			OptStr(`f:v`),
+			[]LongOpt{
+				{Name: "file", HasArg: RequiredArgument, Char: 'f'},
+				{Name: "input", HasArg: RequiredArgument, Char: 'f'},
+			},
+		},
+		{`a()b(bytes`, OptStr(`ab`), []LongOpt{{Name: "bytes", Char: 'b'}}},
+	}
+
+	for _, test := range tests {
+		opts, longOpts := SolarisOptStr(test.optStr)
+		if !slices.Equal(opts, test.wantOpts) {
+			t.Errorf("SolarisOptStr(%q) got Opts %+v, but wanted %+v", test.optStr, opts, test.wantOpts)
+		}
+		if !slices.Equal(longOpts, test.wantLongOpts) {
+			t.Errorf("SolarisOptStr(%q) got LongOpts %+v, but wanted %+v", test.optStr, longOpts, test.wantLongOpts)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	s := NewState(argsStr(`prgm p1 -a -b`))
+	c := Config{Opts: OptStr(`ab`)}
+
+	clone := s.Clone()
+
+	assertGetOpt(t, clone, c, assertion{
+		char:   'a',
+		args:   argsStr(`prgm -a p1 -b`),
+		optInd: 2,
+	})
+
+	wantArgs := argsStr(`prgm p1 -a -b`)
+	if !slices.Equal(s.args, wantArgs) {
+		t.Errorf("got Args %+q, but wanted %+q", s.args, wantArgs)
+	}
+	if s.optInd != 1 {
+		t.Errorf("got optInd %d, but wanted %d", s.optInd, 1)
+	}
+
+	assertGetOpt(t, s, c, assertion{
+		char:   'a',
+		args:   argsStr(`prgm -a p1 -b`),
+		optInd: 2,
+	})
+}
+
+func TestPeek(t *testing.T) {
+	s := NewState(argsStr(`prgm -ab p1 -c`))
+	c := Config{Opts: OptStr(`abc`)}
+
+	wants := []rune{'a', 'b', 'c'}
+	for _, want := range wants {
+		peeked, err := s.Peek(c)
+		if err != nil {
+			t.Fatalf("got error %q, but didn't expect one", err)
+		}
+		got, _ := s.GetOpt(c)
+		if peeked != got {
+			t.Errorf("peeked %+v, but got %+v", peeked, got)
+		}
+		if got.Char != want {
+			t.Errorf("got Char %q, but wanted %q", got.Char, want)
+		}
+	}
+
+	_, err := s.Peek(c)
+	if err != ErrDone {
+		t.Errorf("got error %v, but wanted %v", err, ErrDone)
+	}
+}
+
 func testState(args string) *State {
 	return NewState(argsStr(args))
 }
 
+// testParse returns the results of parsing args with c, failing the test on any
+// error.
+func testParse(t testing.TB, args string, c Config) []Result {
+	t.Helper()
+
+	results, err := testState(args).Parse(c)
+	if err != nil {
+		t.Fatalf("got error %q, but didn't expect one", err)
+	}
+	return results
+}
+
+// testNormalize composes the only decomposed character used in tests, standing
+// in for NFC normalization.
+func testNormalize(s string) string {
+	return strings.ReplaceAll(s, "e\u0301", "é")
+}
+
 func TestGetOpt_FuncGetOpt(t *testing.T) {
 	function := FuncGetOpt
 
@@ -245,6 +1047,27 @@ func TestGetOpt_FuncGetOpt(t *testing.T) {
 		assertSeq(t, s, c, wants)
 	})
 
+	t.Run("it parses normalized short opts", func(t *testing.T) {
+		s := testState("prgm -ae\u0301b x -é -e")
+		c := Config{
+			Opts:      OptStr(`aéb:`),
+			Func:      function,
+			Mode:      ModeGNU,
+			Normalize: testNormalize,
+		}
+		args := argsStr("prgm -ae\u0301b x -é -e")
+		wants := []assertion{
+			{char: 'a', args: args, optInd: 1},
+			{char: 'é', args: args, optInd: 1},
+			{char: 'b', optArg: "x", args: args, optInd: 3},
+			{char: 'é', args: args, optInd: 4},
+			{char: 'e', err: ErrUnknownOpt, args: args, optInd: 5},
+			{err: ErrDone, args: args, optInd: 5},
+		}
+
+		assertSeq(t, s, c, wants)
+	})
+
 	t.Run("it permutes parameters in gnu mode", func(t *testing.T) {
 		s := testState(`prgm -a p1 p2 -b arg1 p3 p4 -c -- p5`)
 		c := Config{
@@ -355,6 +1178,200 @@ func TestGetOpt_FuncGetOptLong(t *testing.T) {
 		assertSeq(t, s, c, wants)
 	})
 
+	t.Run("it parses negated long opts", func(t *testing.T) {
+		s := testState(`prgm --color --no-color --no-v --no --no- --no-color=x`)
+		c := Config{
+			LongOpts: LongOptStr(`[no-]color,[no-]verify,notify`),
+			Func:     function,
+			Mode:     ModeGNU,
+		}
+		args := argsStr(`prgm --color --no-color --no-v --no --no- --no-color=x`)
+		wants := []assertion{
+			{name: "color", args: args, optInd: 2},
+			{name: "color", negated: true, args: args, optInd: 3},
+			{name: "verify", negated: true, args: args, optInd: 4},
+			{name: "notify", args: args, optInd: 5},
+			{name: "no-", err: ErrUnknownOpt, args: args, optInd: 6},
+			{name: "color", negated: true, optArg: "x", err: ErrIllegalOptArg, args: args, optInd: 7},
+			{err: ErrDone, args: args, optInd: 7},
+		}
+
+		assertSeq(t, s, c, wants)
+
+		s = testState(`prgm --no-`)
+		c.LongOpts = LongOptStr(`[no-]color`)
+		args = argsStr(`prgm --no-`)
+		wants = []assertion{
+			{name: "no-", err: ErrUnknownOpt, args: args, optInd: 2},
+			{err: ErrDone, args: args, optInd: 2},
+		}
+
+		assertSeq(t, s, c, wants)
+	})
+
+	t.Run("it parses negatable long opts with arguments", func(t *testing.T) {
+		s := testState(`prgm --level 1 --no-level arg`)
+		c := Config{
+			LongOpts: LongOptStr(`[no-]level:`),
+			Func:     function,
+			Mode:     ModeGNU,
+		}
+		wants := []assertion{
+			{name: "level", optArg: "1", args: argsStr(`prgm --level 1 --no-level arg`), optInd: 3},
+			{name: "level", negated: true, args: argsStr(`prgm --level 1 --no-level arg`), optInd: 4},
+			{err: ErrDone, args: argsStr(`prgm --level 1 --no-level arg`), optInd: 4},
+		}
+
+		assertSeq(t, s, c, wants)
+	})
+
+	t.Run("it parses only exact long opts", func(t *testing.T) {
+		s := testState(`prgm --verbose --verb --no-col --no-color`)
+		c := Config{
+			LongOpts: LongOptStr(`verbose,[no-]color`),
+			Func:     function,
+			Mode:     ModeGNU,
+			Abbrev:   AbbrevExact,
+		}
+		args := argsStr(`prgm --verbose --verb --no-col --no-color`)
+		wants := []assertion{
+			{name: "verbose", args: args, optInd: 2},
+			{name: "verb", err: ErrUnknownOpt, args: args, optInd: 3},
+			{name: "no-col", err: ErrUnknownOpt, args: args, optInd: 4},
+			{name: "color", negated: true, args: args, optInd: 5},
+			{err: ErrDone, args: args, optInd: 5},
+		}
+
+		assertSeq(t, s, c, wants)
+	})
+
+	t.Run("it parses long opts abbreviated to a minimum length", func(t *testing.T) {
+		s := testState(`prgm --ve --ver --no-co --no-col`)
+		c := Config{
+			LongOpts:  LongOptStr(`verbose,[no-]color`),
+			Func:      function,
+			Mode:      ModeGNU,
+			MinAbbrev: 3,
+		}
+		args := argsStr(`prgm --ve --ver --no-co --no-col`)
+		wants := []assertion{
+			{name: "ve", err: ErrUnknownOpt, args: args, optInd: 2},
+			{name: "verbose", args: args, optInd: 3},
+			{name: "no-co", err: ErrUnknownOpt, args: args, optInd: 4},
+			{name: "color", negated: true, args: args, optInd: 5},
+			{err: ErrDone, args: args, optInd: 5},
+		}
+
+		assertSeq(t, s, c, wants)
+	})
+
+	t.Run("it parses per-option abbreviation rules", func(t *testing.T) {
+		s := testState(`prgm --ver --verb --versi --vers --del --delete`)
+		c := Config{
+			LongOpts: []LongOpt{
+				{Name: "verbose", StableAbbrev: "ver"},
+				{Name: "version"},
+				{Name: "delete", NoAbbrev: true},
+			},
+			Func:   function,
+			Mode:   ModeGNU,
+			Abbrev: AbbrevExact,
+		}
+		args := argsStr(`prgm --ver --verb --versi --vers --del --delete`)
+		wants := []assertion{
+			{name: "verbose", args: args, optInd: 2},
+			{name: "verbose", args: args, optInd: 3},
+			{name: "versi", err: ErrUnknownOpt, args: args, optInd: 4},
+			{name: "vers", err: ErrUnknownOpt, args: args, optInd: 5},
+			{name: "del", err: ErrUnknownOpt, args: args, optInd: 6},
+			{name: "delete", args: args, optInd: 7},
+			{err: ErrDone, args: args, optInd: 7},
+		}
+
+		assertSeq(t, s, c, wants)
+	})
+
+	t.Run("it prefers stable abbreviations", func(t *testing.T) {
+		s := testState(`prgm --ver --vers --version`)
+		c := Config{
+			LongOpts: []LongOpt{
+				{Name: "version"},
+				{Name: "verbose", StableAbbrev: "ver"},
+			},
+			Func: function,
+			Mode: ModeGNU,
+		}
+		args := argsStr(`prgm --ver --vers --version`)
+		wants := []assertion{
+			{name: "verbose", args: args, optInd: 2},
+			{name: "version", args: args, optInd: 3},
+			{name: "version", args: args, optInd: 4},
+			{err: ErrDone, args: args, optInd: 4},
+		}
+
+		assertSeq(t, s, c, wants)
+	})
+
+	t.Run("it parses long opts with case folding", func(t *testing.T) {
+		s := testState(`prgm --Verbose --VERB --No-Color --ΣΊΣΥΦΟΣ -V`)
+		c := Config{
+			Opts:     OptStr(`v`),
+			LongOpts: LongOptStr(`verbose,[no-]color,σίσυφος`),
+			Func:     function,
+			Mode:     ModeGNU,
+			FoldCase: true,
+		}
+		args := argsStr(`prgm --Verbose --VERB --No-Color --ΣΊΣΥΦΟΣ -V`)
+		wants := []assertion{
+			{name: "verbose", args: args, optInd: 2},
+			{name: "verbose", args: args, optInd: 3},
+			{name: "color", negated: true, args: args, optInd: 4},
+			{name: "σίσυφος", args: args, optInd: 5},
+			{char: 'V', err: ErrUnknownOpt, args: args, optInd: 6},
+			{err: ErrDone, args: args, optInd: 6},
+		}
+
+		assertSeq(t, s, c, wants)
+	})
+
+	t.Run("it parses normalized long opts", func(t *testing.T) {
+		s := testState("prgm --cafe\u0301 --cafe --café")
+		c := Config{
+			LongOpts:  LongOptStr(`café,cafeteria`),
+			Func:      function,
+			Mode:      ModeGNU,
+			Normalize: testNormalize,
+		}
+		args := argsStr("prgm --cafe\u0301 --cafe --café")
+		wants := []assertion{
+			{name: "café", args: args, optInd: 2},
+			{name: "cafeteria", args: args, optInd: 3},
+			{name: "café", args: args, optInd: 4},
+			{err: ErrDone, args: args, optInd: 4},
+		}
+
+		assertSeq(t, s, c, wants)
+	})
+
+	t.Run("it parses linked long opts", func(t *testing.T) {
+		s := testState(`prgm --all --bytes=10 -b 20`)
+		opts, longOpts := SolarisOptStr(`a(all)b:(bytes)`)
+		c := Config{
+			Opts:     opts,
+			LongOpts: longOpts,
+			Func:     function,
+			Mode:     ModeGNU,
+		}
+		wants := []assertion{
+			{char: 'a', name: "all", args: argsStr(`prgm --all --bytes=10 -b 20`), optInd: 2},
+			{char: 'b', name: "bytes", optArg: "10", args: argsStr(`prgm --all --bytes=10 -b 20`), optInd: 3},
+			{char: 'b', optArg: "20", args: argsStr(`prgm --all --bytes=10 -b 20`), optInd: 5},
+			{err: ErrDone, args: argsStr(`prgm --all --bytes=10 -b 20`), optInd: 5},
+		}
+
+		assertSeq(t, s, c, wants)
+	})
+
 	t.Run("it parses long opts with required arguments", func(t *testing.T) {
 		s := testState(`prgm --longa arg1 --longb=arg2 --longc`)
 		c := Config{
@@ -624,12 +1641,13 @@ func TestGetOpt_FuncGetOptLongOnly(t *testing.T) {
 }
 
 type assertion struct {
-	char   rune
-	name   string
-	optArg string
-	err    error
-	args   []string
-	optInd int
+	char    rune
+	name    string
+	optArg  string
+	negated bool
+	err     error
+	args    []string
+	optInd  int
 }
 
 func assertGetOpt(t testing.TB, s *State, p Config, want assertion) {
@@ -646,6 +1664,9 @@ func assertGetOpt(t testing.TB, s *State, p Config, want assertion) {
 	if res.OptArg != want.optArg {
 		t.Errorf("got OptArg %q, but wanted %q", res.OptArg, want.optArg)
 	}
+	if res.Negated != want.negated {
+		t.Errorf("got Negated %t, but wanted %t", res.Negated, want.negated)
+	}
 	if !slices.Equal(s.args, want.args) {
 		t.Errorf("got Args %v, but wanted %v", s.args, want.args)
 	}
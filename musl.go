@@ -0,0 +1,183 @@
+package getopt
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// The functions in this file emulate getopt, getopt_long and getopt_long_only
+// from [musl-libc], and are used under the MIT License:
+// Copyright © 2005-2020 Rich Felker, et al.
+//
+// Unlike GNU libc, musl only permutes arguments in getopt_long and
+// getopt_long_only, reports ambiguous long option abbreviations as unknown
+// options, and treats "-" as the end of options in every mode.
+
+func (s *State) getOptMusl(c Config) (res Result, err error) {
+	if s.optInd >= len(s.args) {
+		return s.finish(DoneEnd)
+	}
+
+	s.done = DoneNone
+	s.termInd = initTermInd
+
+	if c.Func == FuncGetOpt {
+		ind, arg := s.optInd, s.args[s.optInd]
+		res, err = s.readOptMusl(c)
+		return describe(c, arg, ind, res, err)
+	}
+
+	skipped := s.optInd
+	if c.Mode == ModeGNU {
+		i := s.optInd
+		for ; ; i++ {
+			if i >= len(s.args) {
+				return s.finish(DoneEnd)
+			}
+			if arg := s.args[i]; len(arg) > 1 && arg[0] == '-' {
+				break
+			}
+		}
+		s.optInd = i
+	}
+	resumed := s.optInd
+
+	res, err = s.readLongOptMusl(c)
+	res, err = describe(c, s.args[resumed], resumed, res, err)
+
+	if resumed > skipped {
+		// When a missing option argument is the final argument, musl advances
+		// optind past the end of argv and permutes its terminating null
+		// pointer. Only the arguments that exist are permuted here.
+		count := min(s.optInd, len(s.args)) - resumed
+		for i := 0; i < count; i++ {
+			s.permute(min(s.optInd, len(s.args))-1, skipped)
+		}
+		s.optInd = skipped + s.optInd - resumed
+	}
+	return res, err
+}
+
+func (s *State) readLongOptMusl(c Config) (res Result, err error) {
+	arg := s.args[s.optInd]
+	longOnly := c.Func == FuncGetOptLongOnly
+
+	if len(arg) > 1 && arg[0] == '-' && (longOnly && arg[1] != '-' || arg[1] == '-' && len(arg) > 2) {
+		start := arg[1:]
+		typed := strings.TrimPrefix(start, "-")
+		name, inline, foundInline := strings.Cut(typed, "=")
+		nameEnd := len(start) - len(typed) + len(name)
+
+		count, match := 0, 0
+		for i, lo := range c.LongOpts {
+			if !strings.HasPrefix(lo.Name, name) {
+				continue
+			}
+			match = i
+			if lo.Name == name {
+				count = 1
+				break
+			}
+			count++
+		}
+
+		if count == 1 && longOnly {
+			if _, size := utf8.DecodeRuneInString(start); size == nameEnd && inOptStrMusl(start[:nameEnd], c) {
+				count++
+			}
+		}
+
+		if count == 1 {
+			opt := c.LongOpts[match]
+			s.optInd++
+			res = Result{Kind: KindLongOpt, Char: opt.Char, Name: opt.Name}
+			if foundInline {
+				res.OptArg, res.HasOptArg = inline, true
+				if opt.HasArg == NoArgument {
+					return res, ErrIllegalOptArg
+				}
+			} else if opt.HasArg == RequiredArgument {
+				if s.optInd >= len(s.args) {
+					return res, ErrMissingOptArg
+				}
+				res.OptArg, res.HasOptArg = s.args[s.optInd], true
+				s.optInd++
+			}
+			return res, nil
+		}
+
+		if arg[1] == '-' {
+			s.optInd++
+			return Result{Kind: KindLongOpt, Name: name}, ErrUnknownOpt
+		}
+	}
+
+	return s.readOptMusl(c)
+}
+
+func (s *State) readOptMusl(c Config) (res Result, err error) {
+	arg := s.args[s.optInd]
+
+	if arg == "" || arg[0] != '-' {
+		if c.Mode == ModeInOrder {
+			s.optInd++
+			return Result{Kind: KindParam, Char: '\x01', OptArg: arg, HasOptArg: true}, nil
+		}
+		return s.finish(DoneParam)
+	}
+
+	if arg == "-" {
+		return s.finish(DoneParam)
+	}
+
+	if arg == "--" {
+		return s.terminate(c)
+	}
+
+	if s.argInd == 0 {
+		s.argInd++
+	}
+	offset := s.argInd
+	char, size := utf8.DecodeRuneInString(arg[s.argInd:])
+	s.argInd += size
+	if s.argInd >= len(arg) {
+		s.optInd++
+		s.argInd = 0
+	}
+
+	res = Result{Kind: KindShortOpt, Char: char, offset: offset}
+	opt, found := findOpt(char, c)
+	if !found || char == ':' {
+		return res, ErrUnknownOpt
+	}
+
+	if opt.HasArg == RequiredArgument || opt.HasArg == OptionalArgument && s.argInd != 0 {
+		if s.optInd < len(s.args) {
+			res.OptArg, res.HasOptArg = s.args[s.optInd][s.argInd:], true
+		}
+		s.optInd++
+		s.argInd = 0
+		if s.optInd > len(s.args) {
+			return res, ErrMissingOptArg
+		}
+	}
+
+	return res, nil
+}
+
+// inOptStrMusl reports whether the option character in str appears in the
+// option string that musl would have been passed for c, including its mode
+// prefix and colons.
+func inOptStrMusl(str string, c Config) bool {
+	char, _ := utf8.DecodeRuneInString(str)
+	switch {
+	case char == ':':
+		return true
+	case char == '+':
+		return c.Mode == ModePOSIX
+	case char == '-':
+		return c.Mode == ModeInOrder
+	}
+	_, found := findOpt(char, c)
+	return found
+}
@@ -0,0 +1,113 @@
+package getopt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Escape sequences used by FormatError when color is enabled.
+const (
+	colorBold  = "\x1b[1m"
+	colorRed   = "\x1b[1;31m"
+	colorReset = "\x1b[0m"
+)
+
+// FormatError formats err for display, like a compiler shows errors. For each
+// [*OptError] (including those joined by [State.ParseAll]), the message is
+// followed by the command line, shell-quoted, with a caret under the invalid
+// option (e.g., under X in -abXc). Other errors are formatted as their message,
+// and a nil error as an empty string.
+//
+// Since parsing can permute the argument order, args must be a copy of the
+// slice used to initialize [State], taken before parsing. If color is set, the
+// message and caret are highlighted with ANSI escape sequences, unless the
+// NO_COLOR environment variable is set.
+func FormatError(args []string, err error, color bool) string {
+	if err == nil {
+		return ""
+	}
+	color = color && os.Getenv("NO_COLOR") == ""
+	var b strings.Builder
+	for _, err := range unjoin(err) {
+		formatError(&b, args, err, color)
+	}
+	return b.String()
+}
+
+func formatError(b *strings.Builder, args []string, err error, color bool) {
+	if color {
+		fmt.Fprintf(b, "%s%v%s\n", colorBold, err, colorReset)
+	} else {
+		fmt.Fprintf(b, "%v\n", err)
+	}
+
+	var optErr *OptError
+	if !errors.As(err, &optErr) || optErr.Result.Ind >= len(args) || optErr.Offset > len(args[optErr.Result.Ind]) {
+		return
+	}
+
+	col := 0
+	words := make([]string, len(args))
+	for i, arg := range args {
+		words[i] = shellQuote(arg)
+		if i < optErr.Result.Ind {
+			col += utf8.RuneCountInString(words[i]) + 1
+		}
+	}
+	arg := args[optErr.Result.Ind]
+	if words[optErr.Result.Ind] != arg {
+		col += 1 + utf8.RuneCountInString(shellEscape(arg[:optErr.Offset]))
+	} else {
+		col += utf8.RuneCountInString(arg[:optErr.Offset])
+	}
+
+	width := 1
+	if optErr.Result.Kind == KindLongOpt {
+		width = max(width, utf8.RuneCountInString(optErr.Result.Text))
+	}
+	caret := "^" + strings.Repeat("~", width-1)
+	if color {
+		caret = colorRed + caret + colorReset
+	}
+
+	fmt.Fprintf(b, "  %s\n", strings.Join(words, " "))
+	fmt.Fprintf(b, "  %s%s\n", strings.Repeat(" ", col), caret)
+}
+
+// unjoin returns the errors joined in err by [errors.Join], or err itself.
+func unjoin(err error) []error {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, err := range joined.Unwrap() {
+		errs = append(errs, unjoin(err)...)
+	}
+	return errs
+}
+
+// shellQuote returns str quoted for a POSIX shell, if needed.
+func shellQuote(str string) string {
+	if str != "" && strings.IndexFunc(str, isShellUnsafe) < 0 {
+		return str
+	}
+	return "'" + shellEscape(str) + "'"
+}
+
+// shellEscape returns str escaped to appear within single quotes.
+func shellEscape(str string) string {
+	return strings.ReplaceAll(str, "'", `'\''`)
+}
+
+// isShellUnsafe reports whether r must be quoted for a POSIX shell.
+func isShellUnsafe(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', isDigit(r):
+		return false
+	}
+	return !strings.ContainsRune("@%+=:,./-_", r)
+}
@@ -0,0 +1,149 @@
+package getopt
+
+import (
+	"testing"
+)
+
+func TestFormatError(t *testing.T) {
+	tests := []struct {
+		label  string
+		args   string
+		libc   Libc
+		bundle Bundle
+		want   string
+	}{
+		{
+			label: "unknown short opt",
+			args:  `prgm -x`,
+			want: "getopt: unrecognized option: -x\n" +
+				"  prgm -x\n" +
+				"        ^\n",
+		},
+		{
+			label: "unknown short opt in a group",
+			args:  `prgm -abXc`,
+			want: "getopt: unrecognized option: -X\n" +
+				"  prgm -abXc\n" +
+				"          ^\n",
+		},
+		{
+			label: "unknown short opt in a group with musl",
+			args:  `prgm -abXc`,
+			libc:  LibcMusl,
+			want: "getopt: unrecognized option: -X\n" +
+				"  prgm -abXc\n" +
+				"          ^\n",
+		},
+		{
+			label: "unknown short opt in a group with BSD",
+			args:  `prgm -abXc`,
+			libc:  LibcBSD,
+			want: "getopt: unrecognized option: -X\n" +
+				"  prgm -abXc\n" +
+				"          ^\n",
+		},
+		{
+			label:  "grouped opt with argument",
+			args:   `prgm -aco p1`,
+			bundle: BundleNoArg,
+			want: "getopt: option may not be grouped: -aco\n" +
+				"  prgm -aco p1\n" +
+				"          ^\n",
+		},
+		{
+			label:  "grouped opt repeated",
+			args:   `prgm -aa`,
+			bundle: BundleNone,
+			want: "getopt: option may not be grouped: -aa\n" +
+				"  prgm -aa\n" +
+				"         ^\n",
+		},
+		{
+			label: "unknown long opt",
+			args:  `prgm 'p 1' --verbsoe=1`,
+			want: "getopt: unrecognized option: --verbsoe (did you mean --verbose?)\n" +
+				"  prgm 'p 1' --verbsoe=1\n" +
+				"             ^~~~~~~~~\n",
+		},
+		{
+			label: "unknown short opts in a quoted group",
+			args:  `prgm "-aX'"`,
+			want: "getopt: unrecognized option: -X\n" +
+				"  prgm '-aX'\\'''\n" +
+				"          ^\n" +
+				"getopt: unrecognized option: -'\n" +
+				"  prgm '-aX'\\'''\n" +
+				"           ^\n",
+		},
+		{
+			label: "missing argument",
+			args:  `prgm -x -o`,
+			want: "getopt: unrecognized option: -x\n" +
+				"  prgm -x -o\n" +
+				"        ^\n" +
+				"getopt: option requires an argument: -o\n" +
+				"  prgm -x -o\n" +
+				"           ^\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.label, func(t *testing.T) {
+			s := testState(test.args)
+			c := Config{
+				Opts:     OptStr(`abco:`),
+				LongOpts: LongOptStr(`verbose`),
+				Func:     FuncGetOptLong,
+				Libc:     test.libc,
+				Bundle:   test.bundle,
+			}
+			_, err := s.ParseAll(c)
+
+			got := FormatError(argsStr(test.args), err, false)
+			if got != test.want {
+				t.Errorf("got:\n%s\nbut wanted:\n%s", got, test.want)
+			}
+		})
+	}
+
+	t.Run("without position", func(t *testing.T) {
+		got := FormatError(argsStr(`prgm`), ErrRequiredOpt, false)
+		want := "getopt: missing required option\n"
+
+		if got != want {
+			t.Errorf("got %q, but wanted %q", got, want)
+		}
+	})
+
+	t.Run("without error", func(t *testing.T) {
+		if got := FormatError(argsStr(`prgm`), nil, false); got != "" {
+			t.Errorf("got %q, but wanted %q", got, "")
+		}
+	})
+
+	t.Run("with color", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "")
+		_, err := testState(`prgm -x`).ParseAll(Config{})
+		got := FormatError(argsStr(`prgm -x`), err, true)
+		want := "\x1b[1mgetopt: unrecognized option: -x\x1b[0m\n" +
+			"  prgm -x\n" +
+			"        \x1b[1;31m^\x1b[0m\n"
+
+		if got != want {
+			t.Errorf("got %q, but wanted %q", got, want)
+		}
+	})
+
+	t.Run("with NO_COLOR", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "1")
+		_, err := testState(`prgm -x`).ParseAll(Config{})
+		got := FormatError(argsStr(`prgm -x`), err, true)
+		want := "getopt: unrecognized option: -x\n" +
+			"  prgm -x\n" +
+			"        ^\n"
+
+		if got != want {
+			t.Errorf("got %q, but wanted %q", got, want)
+		}
+	})
+}
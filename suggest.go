@@ -0,0 +1,105 @@
+package getopt
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// suggest returns a valid option similar to the unknown option in res, which
+// was parsed from arg, or "" if there is none. It catches misspelled long
+// option names (--verbsoe), long options typed with a single dash (-verbose),
+// short options typed with two dashes (--v) and short options typed in the
+// wrong case (-V). It also completes abbreviations that were not accepted
+// (e.g., --verb with [AbbrevExact]).
+func suggest(arg string, res Result, c Config) string {
+	prefix := string(res.Prefix)
+	switch res.Kind {
+	case KindLongOpt:
+		if char, size := utf8.DecodeRuneInString(res.Name); size > 0 && size == len(res.Name) {
+			if _, found := findOpt(char, c); found {
+				return prefix + res.Name
+			}
+		}
+		if name := completeLongOpt(res.Name, c); name != "" {
+			return prefix + prefix + name
+		}
+		if name := closestLongOpt(res.Name, c); name != "" {
+			return prefix + prefix + name
+		}
+	case KindShortOpt:
+		if c.Func != FuncGetOpt && !strings.HasPrefix(arg, prefix+prefix) && utf8.RuneCountInString(arg) > 2 {
+			word, _, _ := strings.Cut(arg[len(prefix):], "=")
+			if name := closestLongOpt(word, c); name != "" {
+				return prefix + prefix + name
+			}
+		}
+		for char := unicode.SimpleFold(res.Char); char != res.Char; char = unicode.SimpleFold(char) {
+			if _, found := findOpt(char, c); found {
+				return prefix + string(char)
+			}
+		}
+	}
+	return ""
+}
+
+// completeLongOpt returns the name of the only long option that begins with
+// name, or "" if there is not exactly one.
+func completeLongOpt(name string, c Config) (completed string) {
+	for _, lo := range c.LongOpts {
+		if hasNamePrefix(lo.Name, name, c) {
+			if completed != "" {
+				return ""
+			}
+			completed = lo.Name
+		}
+	}
+	return completed
+}
+
+// closestLongOpt returns the name (or negated name) of the long option with the
+// smallest edit distance to name, if it is close enough to be a likely typo.
+func closestLongOpt(name string, c Config) (closest string) {
+	limit := max(1, utf8.RuneCountInString(name)/3)
+	best := limit + 1
+	try := func(candidate string) {
+		if d := editDistance(name, candidate); d < best {
+			closest, best = candidate, d
+		}
+	}
+	for _, lo := range c.LongOpts {
+		try(lo.Name)
+		if lo.Negatable {
+			try(negPrefix + lo.Name)
+		}
+	}
+	return closest
+}
+
+// editDistance returns the optimal string alignment distance between a and b:
+// the number of rune insertions, deletions, substitutions and transpositions of
+// adjacent runes needed to change a into b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
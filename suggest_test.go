@@ -0,0 +1,115 @@
+package getopt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		label  string
+		args   string
+		f      Func
+		abbrev Abbrev
+		want   string
+	}{
+		{label: "misspelled long opt", args: `prgm --verbsoe`, f: FuncGetOptLong, want: "--verbose"},
+		{label: "misspelled long opt with argument", args: `prgm --levle=3`, f: FuncGetOptLong, want: "--level"},
+		{label: "misspelled negated long opt", args: `prgm --no-colr`, f: FuncGetOptLong, want: "--no-color"},
+		{label: "misspelled long only opt", args: `prgm -verbsoe`, f: FuncGetOptLongOnly, want: "--verbose"},
+		{label: "long opt with a single dash", args: `prgm -verbose`, f: FuncGetOptLong, want: "--verbose"},
+		{label: "short opt with two dashes", args: `prgm --a`, f: FuncGetOptLong, want: "-a"},
+		{label: "short opt in the wrong case", args: `prgm -V`, f: FuncGetOpt, want: "-v"},
+		{label: "abbreviated long opt", args: `prgm --verb`, f: FuncGetOptLong, abbrev: AbbrevExact, want: "--verbose"},
+		{label: "unrelated long opt", args: `prgm --xyz`, f: FuncGetOptLong, want: ""},
+		{label: "unrelated short opt", args: `prgm -x`, f: FuncGetOptLong, want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.label, func(t *testing.T) {
+			s := testState(test.args)
+			c := Config{
+				Opts:           OptStr(`avl:`),
+				LongOpts:       LongOptStr(`verbose,level:,[no-]color`),
+				Func:           test.f,
+				Abbrev:         test.abbrev,
+				DetailedErrors: true,
+			}
+
+			var err error
+			for _, err = range s.All(c) {
+				if err != nil {
+					break
+				}
+			}
+
+			var optErr *OptError
+			if !errors.As(err, &optErr) {
+				t.Fatalf("got error %v, but wanted %T", err, optErr)
+			}
+			if !errors.Is(err, ErrUnknownOpt) {
+				t.Errorf("got error %v, but wanted %v", err, ErrUnknownOpt)
+			}
+			if optErr.Suggestion != test.want {
+				t.Errorf("got Suggestion %q, but wanted %q", optErr.Suggestion, test.want)
+			}
+		})
+	}
+}
+
+func TestOptError(t *testing.T) {
+	tests := []struct {
+		args string
+		want string
+	}{
+		{`prgm --verbsoe`, "getopt: unrecognized option: --verbsoe (did you mean --verbose?)"},
+		{`prgm -ax`, "getopt: unrecognized option: -x"},
+		{`prgm --verbose=1`, "getopt: option disallows arguments: --verbose"},
+		{`prgm -a --lev`, "getopt: option requires an argument: --lev"},
+	}
+
+	for _, test := range tests {
+		s := testState(test.args)
+		c := Config{
+			Opts:           OptStr(`a`),
+			LongOpts:       LongOptStr(`verbose,level:`),
+			Func:           FuncGetOptLong,
+			DetailedErrors: true,
+		}
+
+		_, err := s.Parse(c)
+		if err == nil || err.Error() != test.want {
+			t.Errorf("got error %v, but wanted %q", err, test.want)
+		}
+	}
+}
+
+func TestOptError_Disabled(t *testing.T) {
+	s := testState(`prgm --verbsoe`)
+	c := Config{LongOpts: LongOptStr(`verbose`), Func: FuncGetOptLong}
+
+	if _, err := s.GetOpt(c); err != ErrUnknownOpt {
+		t.Errorf("got error %v, but wanted %v", err, ErrUnknownOpt)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"verbose", "verbose", 0},
+		{"verbsoe", "verbose", 1},
+		{"colr", "color", 1},
+		{"kitten", "sitting", 3},
+		{"café", "cafe", 1},
+	}
+
+	for _, test := range tests {
+		if got := editDistance(test.a, test.b); got != test.want {
+			t.Errorf("editDistance(%q, %q) got %d, but wanted %d", test.a, test.b, got, test.want)
+		}
+	}
+}
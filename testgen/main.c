@@ -419,6 +419,8 @@ static bool handle_case(json_t* item, const size_t index, json_t* results_array)
             int opt;
             int longindex = 0;
             for (;;) {
+                // some implementations (e.g., musl) only assign optarg for options that take arguments
+                optarg = NULL;
                 if (func == GETOPT_FUNC_GETOPT) {
                     opt = getopt(argc, argv, optstring);
                 }
@@ -450,6 +452,18 @@ static bool handle_case(json_t* item, const size_t index, json_t* results_array)
                                 return true;
                             }
                             json_string_set(json_name, name);
+
+                            // some implementations (e.g., BSD) also return ':' for a disallowed argument
+                            char* inlineOptarg = strchr(argv[optind - 1], '=');
+                            current_lopt = longoptions;
+                            while (inlineOptarg != NULL && (*current_lopt).name != NULL) {
+                                if (strcmp(name, current_lopt->name) == 0 && current_lopt->has_arg == no_argument) {
+                                    json_string_set(json_err, "illegal_opt_arg");
+                                    optarg = inlineOptarg + 1;
+                                    break;
+                                }
+                                current_lopt++;
+                            }
                             free(name);
                         }
                         break;
@@ -476,7 +490,7 @@ static bool handle_case(json_t* item, const size_t index, json_t* results_array)
                                 if (strcmp(name, current_lopt->name) == 0) {
                                     json_string_set(json_err, "illegal_opt_arg");
                                     char* inlineOptarg = strchr(argv[optind - 1], '=');
-                                    if (inlineOptarg != NULL && *(inlineOptarg + 1) != '\0') {
+                                    if (inlineOptarg != NULL) {
                                         optarg = inlineOptarg + 1;
                                     }
                                     break;
@@ -500,11 +514,13 @@ static bool handle_case(json_t* item, const size_t index, json_t* results_array)
                 json_array_append_new(
                     iter_array,
                     json_pack(
-                        "{s:o,s:o,s:s,s:o}",
+                        "{s:o,s:o,s:s,s:b,s:o,s:i}",
                         "char", json_char,
                         "name", json_name,
                         "optarg", optarg ? optarg : "",
-                        "err", json_err));
+                        "has_optarg", optarg != NULL,
+                        "err", json_err,
+                        "optind", optind));
 
                 longindex = 0;
 
@@ -534,23 +550,52 @@ static bool handle_case(json_t* item, const size_t index, json_t* results_array)
     return false;
 }
 
-int main(void) {
-    json_t* root;
+static json_t* load_cases(const char* path) {
     json_error_t json_err;
+    json_t* cases = json_load_file(path, 0, &json_err);
+    if (!cases) {
+        log_err("decoding %s, %s at line %d, col %d", path, json_err.text, json_err.line, json_err.column);
+        return NULL;
+    }
 
-    root = json_load_file(INFILE_PATH, 0, &json_err);
-    if (!root) {
-        log_err("decoding %s, %s at line %d, col %d", INFILE_PATH, json_err.text, json_err.line, json_err.column);
-        json_decref(root);
-        return EXIT_FAILURE;
+    if (!json_is_array(cases)) {
+        log_err("expected input %s to be a json array", path);
+        json_decref(cases);
+        return NULL;
     }
+    log_info("loaded %d cases from %s", json_array_size(cases), path);
 
-    if (!json_is_array(root)) {
-        log_err("expected input to be a json array");
-        json_decref(root);
-        return EXIT_FAILURE;
+    return cases;
+}
+
+// usage: testgen [-o OUTFILE] [INFILE...]
+int main(int argc, char* argv[]) {
+    const char* outfile_path = OUTFILE_PATH;
+    int argi = 1;
+    if (argi + 1 < argc && strcmp(argv[argi], "-o") == 0) {
+        outfile_path = argv[argi + 1];
+        argi += 2;
+    }
+
+    json_t* root = json_array();
+    if (argi == argc) {
+        json_t* cases = load_cases(INFILE_PATH);
+        if (!cases) {
+            json_decref(root);
+            return EXIT_FAILURE;
+        }
+        json_array_extend(root, cases);
+        json_decref(cases);
+    }
+    for (; argi < argc; argi++) {
+        json_t* cases = load_cases(argv[argi]);
+        if (!cases) {
+            json_decref(root);
+            return EXIT_FAILURE;
+        }
+        json_array_extend(root, cases);
+        json_decref(cases);
     }
-    log_info("loaded %d cases", json_array_size(root));
 
     json_t* results_array = json_array();
 
@@ -566,9 +611,9 @@ int main(void) {
     }
     log_info("generated %d fixtures", json_array_size(results_array));
 
-    int err = json_dump_file(results_array, OUTFILE_PATH, JSON_INDENT(4));
+    int err = json_dump_file(results_array, outfile_path, JSON_INDENT(4));
     if (err) {
-        log_err("\"%s\" while opening %s", strerror(errno), OUTFILE_PATH);
+        log_err("\"%s\" while opening %s", strerror(errno), outfile_path);
         json_decref(results_array);
         json_decref(root);
         return EXIT_FAILURE;
@@ -0,0 +1,48 @@
+package getopt
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Exit codes for usage errors.
+const (
+	ExitUsage = 64 // EX_USAGE from sysexits.h
+	ExitGNU   = 2  // the code used by GNU tools (e.g., ls) for usage errors
+)
+
+// Usage defines how [ExitOnError] reports a usage error.
+type Usage struct {
+	Writer io.Writer // destination for the error and usage text (os.Stderr, if unset)
+	Text   string    // usage text printed after the error (e.g., "usage: prgm [-a] file")
+	Code   int       // exit code (ExitUsage, if unset)
+	Exit   func(int) // function called to exit (os.Exit, if unset)
+}
+
+// ExitOnError reports err followed by the usage text, and exits with the code
+// set in u. It does nothing if err is nil or [ErrDone], so it can be called with
+// the error from any parsing function (e.g., [State.Parse]). Enable
+// [Config.DetailedErrors] for the error to name the option as typed.
+func ExitOnError(err error, u Usage) {
+	if err == nil || err == ErrDone {
+		return
+	}
+
+	w := u.Writer
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintln(w, err)
+	if u.Text != "" {
+		fmt.Fprintln(w, strings.TrimSuffix(u.Text, "\n"))
+	}
+
+	exit := u.Exit
+	if exit == nil {
+		exit = os.Exit
+	}
+	exit(cmp.Or(u.Code, ExitUsage))
+}
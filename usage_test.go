@@ -0,0 +1,79 @@
+package getopt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExitOnError(t *testing.T) {
+	tests := []struct {
+		label    string
+		args     string
+		detailed bool
+		text     string
+		code     int
+		want     string
+		wantCode int
+	}{
+		{
+			label:    "with default code",
+			args:     `prgm -x`,
+			text:     "usage: prgm [-a] file",
+			want:     "getopt: unrecognized option\nusage: prgm [-a] file\n",
+			wantCode: ExitUsage,
+		},
+		{
+			label:    "with GNU code",
+			args:     `prgm -x`,
+			text:     "usage: prgm [-a] file\n",
+			code:     ExitGNU,
+			want:     "getopt: unrecognized option\nusage: prgm [-a] file\n",
+			wantCode: ExitGNU,
+		},
+		{
+			label:    "with detailed errors",
+			args:     `prgm -x`,
+			detailed: true,
+			text:     "usage: prgm [-a] file",
+			code:     ExitGNU,
+			want:     "getopt: unrecognized option: -x\nusage: prgm [-a] file\n",
+			wantCode: ExitGNU,
+		},
+		{
+			label:    "without usage text",
+			args:     `prgm -x`,
+			want:     "getopt: unrecognized option\n",
+			wantCode: ExitUsage,
+		},
+		{
+			label:    "without error",
+			args:     `prgm -a`,
+			text:     "usage: prgm [-a] file",
+			want:     "",
+			wantCode: -1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.label, func(t *testing.T) {
+			c := Config{Opts: OptStr(`a`), DetailedErrors: test.detailed}
+			_, err := testState(test.args).Parse(c)
+
+			var got strings.Builder
+			gotCode := -1
+			ExitOnError(err, Usage{
+				Writer: &got,
+				Text:   test.text,
+				Code:   test.code,
+				Exit:   func(code int) { gotCode = code },
+			})
+
+			if got.String() != test.want {
+				t.Errorf("got %q, but wanted %q", got.String(), test.want)
+			}
+			if gotCode != test.wantCode {
+				t.Errorf("got code %d, but wanted %d", gotCode, test.wantCode)
+			}
+		})
+	}
+}